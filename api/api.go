@@ -57,6 +57,46 @@ func (cfg *Config) CreateEndpoints() http.Handler {
 				r.Get("/presigUrl", cfg.GeneratePresignedUrl)
 				r.Post("/verifyUpload", cfg.VerifyUpload)
 				r.Get("/files", cfg.GetFilesForCollection)
+				r.Delete("/files/{fileID}", cfg.DeleteFile)
+				//cards from pasted text or a topic, no upload
+				r.Post("/generate", cfg.GenerateCards)
+
+				//generated cards waiting for review
+				r.Route("/files/{fileID}/drafts", func(r chi.Router) {
+					r.Get("/", cfg.ListDrafts)
+					r.Post("/accept", cfg.AcceptDrafts)
+					r.Post("/reject", cfg.RejectDrafts)
+					r.Put("/{cardID}", cfg.AcceptEditedDraft)
+				})
+
+				//images and audio for cards
+				r.Get("/media", cfg.ListMedia)
+				r.Post("/media", cfg.CreateMediaUpload)
+				r.Post("/media/{mediaID}/verify", cfg.VerifyMediaUpload)
+				r.Delete("/media/{mediaID}", cfg.DeleteMedia)
+
+				//prompt the LLM writes the collection's cards with
+				r.Get("/prompt", cfg.GetPromptTemplate)
+				r.Put("/prompt", cfg.SavePromptTemplate)
+				r.Post("/prompt/preview", cfg.PreviewPrompt)
+				r.Get("/prompt/versions", cfg.ListPromptTemplates)
+				r.Post("/prompt/versions/{version}/restore", cfg.RestorePromptTemplate)
+
+				//questions answered from the text of the files
+				r.Post("/chat", cfg.Chat)
+				r.Post("/chat/card", cfg.ChatToCard)
+
+				//mnemonics for leeches, suggested by the worker and approved one by one
+				r.Post("/mnemonics", cfg.CreateMnemonics)
+				r.Get("/mnemonics", cfg.ListMnemonics)
+				r.Post("/mnemonics/{mnemonicID}/approve", cfg.ApproveMnemonic)
+				r.Post("/mnemonics/{mnemonicID}/reject", cfg.RejectMnemonic)
+
+				r.Get("/export", cfg.ExportCollection)
+				r.Post("/exports/pdf", cfg.CreatePdfExport)
+				r.Get("/exports/pdf/{exportID}", cfg.GetPdfExport)
+				r.Post("/import", cfg.ImportTable)
+				r.Post("/import/text", cfg.ImportText)
 
 				//cards
 				r.Post("/cards", cfg.CreateCard)
@@ -64,11 +104,30 @@ func (cfg *Config) CreateEndpoints() http.Handler {
 					r.Get("/", cfg.GetCard)
 					r.Delete("/", cfg.DeleteCard)
 					r.Put("/", cfg.UpdateCard)
+					r.Get("/related", cfg.RelatedCards)
+					r.Post("/rewrite", cfg.RewriteCard)
+					r.Post("/rewrite/accept", cfg.AcceptRewrite)
+					r.Post("/hint", cfg.CardHint)
+					r.Post("/explanation", cfg.ExplainCard)
 				})
 
 			})
 		})
 
+		router.Route("/search", func(r chi.Router) {
+			r.Use(JWTMiddleware(cfg.JWTKey))
+			r.Get("/", cfg.SearchCards)
+			r.Get("/semantic", cfg.SemanticSearch)
+		})
+
+		router.Route("/trash", func(r chi.Router) {
+			r.Use(JWTMiddleware(cfg.JWTKey))
+			r.Get("/", cfg.GetTrash)
+			r.Post("/collections/{collectionID}/restore", cfg.RestoreCollection)
+			r.Post("/cards/{cardID}/restore", cfg.RestoreCard)
+			r.Post("/files/{fileID}/restore", cfg.RestoreFile)
+		})
+
 		router.Get("/ws", cfg.Sock)
 
 	})
@@ -147,6 +206,19 @@ func getIdFromPath(r *http.Request, key string) (uuid.UUID, error) {
 	return id, nil
 }
 
+// queueEmbedding has the worker embed cards again after they were written.
+// Until it runs the cards are only missing from semantic results, so a failed
+// publish is just logged.
+func (cfg *Config) queueEmbedding(cardIDs ...uuid.UUID) {
+	if len(cardIDs) == 0 {
+		return
+	}
+	err := cfg.Queue.PublishTask(queue.Message{Type: queue.MessageEmbedCards, CardIDs: cardIDs})
+	if err != nil {
+		log.Printf("cannot queue embedding of %d cards: %v", len(cardIDs), err)
+	}
+}
+
 func RespondWithJson(w http.ResponseWriter, code int, data any) {
 	w.Header().Set("content-type", "application/json")
 	w.WriteHeader(code)
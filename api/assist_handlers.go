@@ -0,0 +1,55 @@
+package api
+
+import (
+	"CueMind/internal/llm"
+	"CueMind/internal/server"
+	"errors"
+	"net/http"
+)
+
+// CardHint returns a hint for the card that doesn't give the answer away. It is a
+// POST, the first request for a version of the card has the model write it.
+func (cfg *Config) CardHint(w http.ResponseWriter, r *http.Request) {
+	cfg.cardAssist(w, r, llm.AssistHint)
+}
+
+// ExplainCard returns an explanation of the card's answer, from its source file when it has one.
+func (cfg *Config) ExplainCard(w http.ResponseWriter, r *http.Request) {
+	cfg.cardAssist(w, r, llm.AssistExplain)
+}
+
+func (cfg *Config) cardAssist(w http.ResponseWriter, r *http.Request, kind string) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cardID, err := getIdFromPath(r, "cardID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	//check user owns the collection
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	assist, err := cfg.Server.CardAssist(r.Context(), userID, collectionID, cardID, kind)
+	if errors.Is(err, server.ErrCardNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, assist)
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"CueMind/internal/content"
 	"CueMind/internal/server"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -55,6 +57,10 @@ func (cfg *Config) CreateCard(w http.ResponseWriter, r *http.Request) {
 		RespondWithErr(w, 400, "Card data cannot be empty")
 		return
 	}
+	if !content.ValidFormat(card.Format) {
+		RespondWithErr(w, 400, "format must be plain or markdown")
+		return
+	}
 
 	//check that user owns the collection
 	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
@@ -69,6 +75,7 @@ func (cfg *Config) CreateCard(w http.ResponseWriter, r *http.Request) {
 		RespondWithErr(w, 500, err.Error())
 		return
 	}
+	cfg.queueEmbedding(card.ID)
 	RespondWithJson(w, 200, card)
 }
 
@@ -131,6 +138,10 @@ func (cfg *Config) UpdateCard(w http.ResponseWriter, r *http.Request) {
 	type Data struct {
 		Front string `json:"front"`
 		Back  string `json:"back"`
+		//left out keeps the current tags, [] clears them
+		Tags *[]string `json:"tags"`
+		//empty keeps the current format
+		Format string `json:"format"`
 	}
 	var data Data
 
@@ -139,12 +150,45 @@ func (cfg *Config) UpdateCard(w http.ResponseWriter, r *http.Request) {
 		RespondWithErr(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if !content.ValidFormat(data.Format) {
+		RespondWithErr(w, http.StatusBadRequest, "format must be plain or markdown")
+		return
+	}
 
-	err = cfg.Server.UpdateCard(r.Context(), cardID, data.Front, data.Back)
+	err = cfg.Server.UpdateCard(r.Context(), collectionID, cardID, data.Front, data.Back, data.Tags, data.Format)
+	if errors.Is(err, server.ErrCardNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		RespondWithErr(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	cfg.queueEmbedding(cardID)
 	RespondWithJson(w, 204, nil)
 
 }
+
+func (cfg *Config) RelatedCards(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cardID, err := getIdFromPath(r, "cardID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cards, err := cfg.Server.RelatedCards(r.Context(), userID, cardID)
+	if errors.Is(err, server.ErrEmbeddingNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, cards)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"CueMind/internal/server"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Chat answers a question from the text of the collection's files, with citations.
+func (cfg *Config) Chat(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var q server.ChatQuestion
+	err = json.NewDecoder(r.Body).Decode(&q)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	answer, err := cfg.Server.Chat(r.Context(), collectionID, q)
+	if errors.Is(err, server.ErrInvalidChat) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, server.ErrNoFileText) {
+		RespondWithErr(w, http.StatusNotFound, "the collection has no processed files to answer from")
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, answer)
+}
+
+// ChatToCard saves a chat answer as a card of the collection.
+func (cfg *Config) ChatToCard(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var c server.ChatCard
+	err = json.NewDecoder(r.Body).Decode(&c)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	card, err := cfg.Server.ChatToCard(r.Context(), collectionID, c)
+	if errors.Is(err, server.ErrInvalidChat) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cfg.queueEmbedding(card.ID)
+	RespondWithJson(w, 200, card)
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/server"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *Config) ListDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fileID, err := getIdFromPath(r, "fileID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	cards, err := cfg.Server.ListDrafts(r.Context(), userID, collectionID, fileID)
+	if errors.Is(err, server.ErrFileNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, cards)
+}
+
+func (cfg *Config) AcceptDrafts(w http.ResponseWriter, r *http.Request) {
+	cfg.reviewDrafts(w, r, server.CardStatusActive)
+}
+
+func (cfg *Config) RejectDrafts(w http.ResponseWriter, r *http.Request) {
+	cfg.reviewDrafts(w, r, server.CardStatusRejected)
+}
+
+func (cfg *Config) reviewDrafts(w http.ResponseWriter, r *http.Request, status string) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fileID, err := getIdFromPath(r, "fileID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	//either explicit card IDs or all=true for every draft of the file
+	type Data struct {
+		CardIDs []uuid.UUID `json:"card_ids"`
+		All     bool        `json:"all"`
+	}
+	var data Data
+	err = json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(data.CardIDs) == 0 && !data.All {
+		RespondWithErr(w, http.StatusBadRequest, "provide card_ids or set all to true")
+		return
+	}
+	if data.All {
+		data.CardIDs = nil
+	}
+
+	n, merged, err := cfg.Server.ReviewDrafts(r.Context(), userID, collectionID, fileID, data.CardIDs, status)
+	if errors.Is(err, server.ErrFileNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	//merged backs changed, their vectors have to follow for later similarity checks
+	cfg.queueEmbedding(merged...)
+	RespondWithJson(w, 200, map[string]int64{"updated": n})
+}
+
+func (cfg *Config) AcceptEditedDraft(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fileID, err := getIdFromPath(r, "fileID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cardID, err := getIdFromPath(r, "cardID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var card server.Card
+	err = json.NewDecoder(r.Body).Decode(&card)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(card.Front) == 0 || len(card.Back) == 0 {
+		RespondWithErr(w, 400, "Card data cannot be empty")
+		return
+	}
+	if !content.ValidFormat(card.Format) {
+		RespondWithErr(w, 400, "format must be plain or markdown")
+		return
+	}
+	card.ID = cardID
+
+	err = cfg.Server.AcceptEditedDraft(r.Context(), userID, collectionID, fileID, &card)
+	if errors.Is(err, server.ErrFileNotFound) || errors.Is(err, server.ErrNotADraft) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cfg.queueEmbedding(card.ID)
+	RespondWithJson(w, 200, card)
+}
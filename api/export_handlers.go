@@ -0,0 +1,164 @@
+package api
+
+import (
+	"CueMind/internal/server"
+	queue "CueMind/internal/worker-queue"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// ExportCollection downloads the collection as a file, ?format=apkg for an Anki
+// package or csv/tsv for a table of the cards.
+func (cfg *Config) ExportCollection(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == server.ExportCSV || format == server.ExportTSV {
+		cfg.exportTable(w, r, userID, collectionID, format)
+		return
+	}
+
+	export, err := cfg.Server.ExportCollection(r.Context(), userID, collectionID, format)
+	if errors.Is(err, server.ErrUnknownExportFormat) {
+		RespondWithErr(w, http.StatusBadRequest, "format must be apkg, csv or tsv")
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer export.Close()
+
+	w.Header().Set("Content-Type", export.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName))
+	w.WriteHeader(200)
+	_, err = io.Copy(w, export.File)
+	if err != nil {
+		log.Printf("cannot send export of collection %v: %v", collectionID, err)
+	}
+}
+
+// exportTable streams the cards as they are read from the database, nothing is spooled.
+func (cfg *Config) exportTable(w http.ResponseWriter, r *http.Request, userID, collectionID uuid.UUID, format string) {
+	table, err := cfg.Server.ExportTable(r.Context(), userID, collectionID, format)
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", table.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", table.FileName))
+	w.WriteHeader(200)
+	err = table.Write(r.Context(), w)
+	if err != nil {
+		log.Printf("cannot send export of collection %v: %v", collectionID, err)
+	}
+}
+
+// CreatePdfExport queues a printout of the collection. The worker reports the
+// download link on the websocket registered with the returned id.
+func (cfg *Config) CreatePdfExport(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var req struct {
+		Layout string `json:"layout"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	export, err := cfg.Server.CreatePdfExport(r.Context(), userID, collectionID, req.Layout)
+	if errors.Is(err, server.ErrUnknownLayout) {
+		RespondWithErr(w, http.StatusBadRequest, "layout must be cards or sheet")
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = cfg.Queue.PublishTask(queue.Message{
+		Type:         queue.MessagePdfExport,
+		UserID:       userID,
+		CollectionID: collectionID,
+		FileKey:      export.ID.String(),
+		FileName:     export.CollectionName,
+		Layout:       export.Layout,
+	})
+	if err != nil {
+		log.Println(err)
+		err = cfg.Server.FailPdfExport(r.Context(), export.ID)
+		if err != nil {
+			log.Printf("cannot mark export %v as failed: %v", export.ID, err)
+		}
+		RespondWithErr(w, 500, "cannot publish to queue")
+		return
+	}
+
+	RespondWithJson(w, http.StatusAccepted, export)
+}
+
+// GetPdfExport returns the status of a printout, with a download link once it is ready.
+func (cfg *Config) GetPdfExport(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	exportID, err := getIdFromPath(r, "exportID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	export, err := cfg.Server.GetPdfExport(r.Context(), userID, collectionID, exportID)
+	if err != nil {
+		RespondWithErr(w, 404, err.Error())
+		return
+	}
+
+	RespondWithJson(w, 200, export)
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"CueMind/internal/llm"
 	"CueMind/internal/server"
 	queue "CueMind/internal/worker-queue"
 	"encoding/json"
@@ -74,11 +75,16 @@ func (cfg *Config) VerifyUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	//get data from request body
 	type Verify struct {
-		Status    string `json:"status"`
-		ObjectKey string `json:"object_key"`
-		FileName  string `json:"file_name"`
-		Format    string `json:"format"`
-		Error     string `json:"error"`
+		Status        string `json:"status"`
+		ObjectKey     string `json:"object_key"`
+		FileName      string `json:"file_name"`
+		Format        string `json:"format"`
+		Error         string `json:"error"`
+		DuplicateMode string `json:"duplicate_mode"`
+		KeepHistory   bool   `json:"keep_history"`
+		VaultMode     string `json:"vault_mode"`
+		//how the LLM writes the cards, all optional
+		Options llm.Options `json:"options"`
 	}
 	var verify Verify
 	err = json.NewDecoder(r.Body).Decode(&verify)
@@ -87,6 +93,20 @@ func (cfg *Config) VerifyUpload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !queue.ValidDuplicateMode(verify.DuplicateMode) {
+		RespondWithErr(w, http.StatusBadRequest, "duplicate_mode must be skip, merge or flag")
+		return
+	}
+	if !queue.ValidVaultMode(verify.VaultMode) {
+		RespondWithErr(w, http.StatusBadRequest, "vault_mode must be syntax or llm")
+		return
+	}
+	err = verify.Options.Validate()
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	//convert objetKey to valid UUID
 	fileID, err := uuid.Parse(verify.ObjectKey)
 	if err != nil {
@@ -124,11 +144,24 @@ func (cfg *Config) VerifyUpload(w http.ResponseWriter, r *http.Request) {
 
 	//send it to the queue
 	queueMsg := queue.Message{
-		UserID:       userID,
-		CollectionID: collectionID,
-		FileKey:      verify.ObjectKey,
-		FileName:     verify.FileName,
-		Format:       verify.Format,
+		Type:          queue.MessageGenerateCards,
+		UserID:        userID,
+		CollectionID:  collectionID,
+		FileKey:       verify.ObjectKey,
+		FileName:      verify.FileName,
+		Format:        verify.Format,
+		DuplicateMode: verify.DuplicateMode,
+		Options:       verify.Options,
+	}
+	//anki packages are imported as they are, a package with several decks becomes one collection per deck
+	if verify.Format == "apkg" {
+		queueMsg.Type = queue.MessageAnkiImport
+		queueMsg.KeepHistory = verify.KeepHistory
+	}
+	//a zip of markdown notes is synced with the cards of earlier imports of the same vault
+	if verify.Format == "zip" {
+		queueMsg.Type = queue.MessageVaultImport
+		queueMsg.VaultMode = verify.VaultMode
 	}
 
 	err = cfg.Queue.PublishTask(queueMsg)
@@ -141,3 +174,36 @@ func (cfg *Config) VerifyUpload(w http.ResponseWriter, r *http.Request) {
 	RespondWithJson(w, 200, map[string]string{"status": "success", "filename": file.Filename})
 
 }
+
+func (cfg *Config) DeleteFile(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fileID, err := getIdFromPath(r, "fileID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	//check user owns the collection
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	withCards := r.URL.Query().Get("delete_cards") == "true"
+	err = cfg.Server.DeleteCollectionFile(r.Context(), collectionID, fileID, withCards)
+	if err != nil {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	RespondWithJson(w, 204, nil)
+}
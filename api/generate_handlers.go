@@ -0,0 +1,82 @@
+package api
+
+import (
+	"CueMind/internal/server"
+	queue "CueMind/internal/worker-queue"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// GenerateCards queues card generation for pasted text or a topic, no upload needed.
+// The cards arrive as drafts of the returned file, the websocket registered with
+// its id is told when they are ready.
+func (cfg *Config) GenerateCards(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var req server.TextGeneration
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+	if !queue.ValidDuplicateMode(req.DuplicateMode) {
+		RespondWithErr(w, http.StatusBadRequest, "duplicate_mode must be skip, merge or flag")
+		return
+	}
+	err = req.Options.Validate()
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	file, err := cfg.Server.CreateTextSource(r.Context(), userID, collectionID, req)
+	if errors.Is(err, server.ErrInvalidGeneration) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = cfg.Queue.PublishTask(queue.Message{
+		Type:          queue.MessageGenerateText,
+		UserID:        userID,
+		CollectionID:  collectionID,
+		FileKey:       file.ID.String(),
+		FileName:      file.Filename,
+		Format:        file.Format,
+		DuplicateMode: req.DuplicateMode,
+		Options:       req.Options,
+		Topic:         req.Topic,
+	})
+	if err != nil {
+		log.Println(err)
+		err = cfg.Server.DeleteFile(r.Context(), file.ID)
+		if err != nil {
+			log.Printf("cannot delete file %v: %v", file.ID, err)
+		}
+		RespondWithErr(w, 500, "cannot publish to queue")
+		return
+	}
+
+	RespondWithJson(w, http.StatusAccepted, map[string]string{"status": "queued", "file_id": file.ID.String(), "filename": file.Filename})
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"CueMind/internal/server"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// largest import request body, about maxImportRows rows of long cards
+const maxImportBody = 10 << 20
+
+// ImportTable creates cards from a CSV or TSV table. With dry_run the report
+// previews the cards without saving them.
+func (cfg *Config) ImportTable(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var data server.TableImport
+	err = json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportBody)).Decode(&data)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		RespondWithErr(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	report, err := cfg.Server.ImportTable(r.Context(), collectionID, data)
+	if errors.Is(err, server.ErrInvalidImport) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, server.ErrCollectionDeleted) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cfg.queueEmbedding(report.CreatedIDs()...)
+	RespondWithJson(w, 200, report)
+}
+
+// ImportText creates cards from a pasted Quizlet style list. With dry_run the
+// report previews the cards without saving them.
+func (cfg *Config) ImportText(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var data server.TextImport
+	err = json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImportBody)).Decode(&data)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		RespondWithErr(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	report, err := cfg.Server.ImportText(r.Context(), collectionID, data)
+	if errors.Is(err, server.ErrInvalidImport) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, server.ErrCollectionDeleted) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cfg.queueEmbedding(report.CreatedIDs()...)
+	RespondWithJson(w, 200, report)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"CueMind/internal/server"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *Config) ListMedia(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	media, err := cfg.Server.ListMedia(r.Context(), collectionID)
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, media)
+}
+
+// CreateMediaUpload works like GeneratePresignedUrl for files: the client uploads
+// to the returned url, then confirms with VerifyMediaUpload.
+func (cfg *Config) CreateMediaUpload(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	type Data struct {
+		ContentType string `json:"content_type"`
+		//bytes the client will upload, the URL only accepts exactly this many
+		Size   int64      `json:"size"`
+		CardID *uuid.UUID `json:"card_id"`
+	}
+	var data Data
+	err = json.NewDecoder(r.Body).Decode(&data)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var cardID uuid.NullUUID
+	if data.CardID != nil {
+		cardID = uuid.NullUUID{UUID: *data.CardID, Valid: true}
+	}
+	media, url, err := cfg.Server.CreateMediaUpload(r.Context(), userID, collectionID, cardID, data.ContentType, data.Size)
+	if errors.Is(err, server.ErrUnsupportedMedia) || errors.Is(err, server.ErrInvalidMediaSize) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	type Response struct {
+		server.Media
+		UploadURL string `json:"upload_url"`
+	}
+	RespondWithJson(w, 200, Response{Media: *media, UploadURL: url})
+}
+
+func (cfg *Config) VerifyMediaUpload(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	mediaID, err := getIdFromPath(r, "mediaID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	type Verify struct {
+		Status string `json:"status"`
+	}
+	var verify Verify
+	err = json.NewDecoder(r.Body).Decode(&verify)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	err = cfg.Server.VerifyMediaUpload(r.Context(), collectionID, mediaID, verify.Status == "success")
+	if errors.Is(err, server.ErrMediaNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, server.ErrInvalidUpload) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, map[string]string{"status": verify.Status})
+}
+
+func (cfg *Config) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	mediaID, err := getIdFromPath(r, "mediaID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	err = cfg.Server.DeleteMedia(r.Context(), collectionID, mediaID)
+	if err != nil {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	RespondWithJson(w, 204, nil)
+}
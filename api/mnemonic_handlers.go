@@ -0,0 +1,171 @@
+package api
+
+import (
+	"CueMind/internal/server"
+	queue "CueMind/internal/worker-queue"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// CreateMnemonics queues mnemonics for the leeches of the collection. The worker
+// reports on the websocket registered with the returned id, the mnemonics wait
+// for approval in ListMnemonics.
+func (cfg *Config) CreateMnemonics(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var req struct {
+		//0 for the default
+		MinLapses int `json:"min_lapses"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	job, err := cfg.Server.NewMnemonicJob(r.Context(), userID, collectionID, req.MinLapses)
+	if errors.Is(err, server.ErrInvalidMnemonics) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = cfg.Queue.PublishTask(queue.Message{
+		Type:         queue.MessageMnemonics,
+		UserID:       userID,
+		CollectionID: collectionID,
+		FileKey:      job.ID.String(),
+		FileName:     job.CollectionName,
+		MinLapses:    job.MinLapses,
+	})
+	if err != nil {
+		log.Println(err)
+		RespondWithErr(w, 500, "cannot publish to queue")
+		return
+	}
+
+	RespondWithJson(w, http.StatusAccepted, job)
+}
+
+// ListMnemonics returns the collection's mnemonics, ?status=approved or rejected
+// for those instead of the pending ones.
+func (cfg *Config) ListMnemonics(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	mnemonics, err := cfg.Server.ListMnemonics(r.Context(), collectionID, r.URL.Query().Get("status"))
+	if errors.Is(err, server.ErrInvalidMnemonics) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, mnemonics)
+}
+
+func (cfg *Config) ApproveMnemonic(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	mnemonicID, err := getIdFromPath(r, "mnemonicID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	err = cfg.Server.ApproveMnemonic(r.Context(), collectionID, mnemonicID)
+	if errors.Is(err, server.ErrMnemonicNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 204, nil)
+}
+
+func (cfg *Config) RejectMnemonic(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	mnemonicID, err := getIdFromPath(r, "mnemonicID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	err = cfg.Server.RejectMnemonic(r.Context(), collectionID, mnemonicID)
+	if errors.Is(err, server.ErrMnemonicNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 204, nil)
+}
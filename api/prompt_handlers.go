@@ -0,0 +1,176 @@
+package api
+
+import (
+	"CueMind/internal/server"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetPromptTemplate returns the template the collection's cards are generated with.
+func (cfg *Config) GetPromptTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	template, err := cfg.Server.GetPromptTemplate(r.Context(), collectionID)
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, template)
+}
+
+func (cfg *Config) ListPromptTemplates(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	templates, err := cfg.Server.ListPromptTemplates(r.Context(), collectionID)
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, templates)
+}
+
+// SavePromptTemplate adds a new version of the collection's template.
+func (cfg *Config) SavePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var req struct {
+		Body string `json:"body"`
+	}
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	template, err := cfg.Server.SavePromptTemplate(r.Context(), collectionID, req.Body)
+	if errors.Is(err, server.ErrInvalidTemplate) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 201, template)
+}
+
+// RestorePromptTemplate makes an earlier version the current one again, version 0 the built-in template.
+func (cfg *Config) RestorePromptTemplate(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+	version, err := strconv.ParseInt(chi.URLParam(r, "version"), 10, 32)
+	if err != nil || version < 0 {
+		RespondWithErr(w, http.StatusBadRequest, "version must be a number")
+		return
+	}
+
+	template, err := cfg.Server.RestorePromptTemplate(r.Context(), collectionID, int32(version))
+	if err != nil {
+		RespondWithErr(w, 404, err.Error())
+		return
+	}
+	RespondWithJson(w, 201, template)
+}
+
+// PreviewPrompt renders a template with the given options and the collection's cards,
+// exactly as the model would get it.
+func (cfg *Config) PreviewPrompt(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var req server.PromptPreview
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	prompt, err := cfg.Server.PreviewPrompt(r.Context(), collectionID, req.Template, req.Options)
+	if errors.Is(err, server.ErrInvalidTemplate) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, map[string]string{"prompt": prompt})
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"CueMind/internal/server"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RewriteCard returns an AI rewrite of the card for the user to accept, the card isn't changed.
+func (cfg *Config) RewriteCard(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cardID, err := getIdFromPath(r, "cardID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	//check user owns the collection
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var req server.RewriteRequest
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	rewrite, err := cfg.Server.RewriteCard(r.Context(), userID, collectionID, cardID, req)
+	if errors.Is(err, server.ErrInvalidRewrite) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, server.ErrCardNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, rewrite)
+}
+
+// AcceptRewrite saves the cards of a rewrite: the first one replaces the card, the rest are added.
+func (cfg *Config) AcceptRewrite(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cardID, err := getIdFromPath(r, "cardID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	//check user owns the collection
+	err = cfg.Server.CheckUserOwnership(r.Context(), collectionID, userID)
+	if err != nil {
+		RespondWithErr(w, 403, err.Error())
+		return
+	}
+
+	var accept server.RewriteAccept
+	err = json.NewDecoder(r.Body).Decode(&accept)
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, fmt.Sprintf("Cannot Decode Json :%v", err))
+		return
+	}
+
+	cards, err := cfg.Server.AcceptRewrite(r.Context(), userID, collectionID, cardID, accept)
+	if errors.Is(err, server.ErrInvalidRewrite) {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, server.ErrCardNotFound) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	ids := make([]uuid.UUID, len(cards))
+	for i := range cards {
+		ids[i] = cards[i].ID
+	}
+	cfg.queueEmbedding(ids...)
+	RespondWithJson(w, 200, cards)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+func (cfg *Config) SearchCards(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		RespondWithErr(w, http.StatusBadRequest, "search query cannot be empty")
+		return
+	}
+
+	//optional filters
+	var collectionID *uuid.UUID
+	if v := r.URL.Query().Get("collection"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			RespondWithErr(w, http.StatusBadRequest, "invalid collection filter")
+			return
+		}
+		collectionID = &id
+	}
+	tags := r.URL.Query()["tag"]
+
+	results, err := cfg.Server.SearchCards(r.Context(), userID, query, collectionID, tags)
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, results)
+}
+
+func (cfg *Config) SemanticSearch(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		RespondWithErr(w, http.StatusBadRequest, "search query cannot be empty")
+		return
+	}
+
+	results, err := cfg.Server.SemanticSearch(r.Context(), userID, query)
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, results)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"CueMind/internal/server"
+	"errors"
+	"net/http"
+)
+
+func (cfg *Config) GetTrash(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trash, err := cfg.Server.GetTrash(r.Context(), userID)
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, trash)
+}
+
+func (cfg *Config) RestoreCollection(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	collectionID, err := getIdFromPath(r, "collectionID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.RestoreCollection(r.Context(), collectionID, userID)
+	respondRestore(w, err)
+}
+
+func (cfg *Config) RestoreCard(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cardID, err := getIdFromPath(r, "cardID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.RestoreCard(r.Context(), cardID, userID)
+	respondRestore(w, err)
+}
+
+func (cfg *Config) RestoreFile(w http.ResponseWriter, r *http.Request) {
+	userID, err := getIdFromContext(r.Context(), "userID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fileID, err := getIdFromPath(r, "fileID")
+	if err != nil {
+		RespondWithErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = cfg.Server.RestoreFile(r.Context(), fileID, userID)
+	respondRestore(w, err)
+}
+
+func respondRestore(w http.ResponseWriter, err error) {
+	if errors.Is(err, server.ErrNotInTrash) {
+		RespondWithErr(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		RespondWithErr(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJson(w, 200, map[string]string{"status": "restored"})
+}
@@ -10,6 +10,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -17,7 +18,13 @@ import (
 func main() {
 	godotenv.Load()
 
-	llmKey := os.Getenv("LLM_KEY")
+	llmCfg := llm.Config{
+		Provider:       os.Getenv("LLM_PROVIDER"),
+		Key:            os.Getenv("LLM_KEY"),
+		Model:          os.Getenv("LLM_MODEL"),
+		BaseURL:        os.Getenv("LLM_BASE_URL"),
+		EmbeddingModel: os.Getenv("LLM_EMBEDDING_MODEL"),
+	}
 	dbUrl := os.Getenv("DB_URL")
 	jwtKey := os.Getenv("JWT_KEY")
 	bucketName := os.Getenv("BUCKET_NAME")
@@ -25,16 +32,27 @@ func main() {
 
 	dbCon, sqlCon := api.DBConnect(dbUrl)
 	storageServer := storage.New(bucketName)
-	server := server.New(dbCon, storageServer, sqlCon)
+	generator, embedder, err := llm.New(llmCfg)
+	if err != nil {
+		log.Fatalf("Error on creating AI client :%v", err)
+	}
+	server := server.New(dbCon, storageServer, sqlCon, embedder, generator)
 	queue := workerqueue.New(rabbitmqURL)
-	llmServer := llm.New(llmKey)
 	hub := ws.New()
 
 	//creating workers
-	workerCfg := workerqueue.NewWorkerConf(sqlCon, dbCon, llmServer, storageServer, rabbitmqURL, hub)
+	workerCfg := workerqueue.NewWorkerConf(sqlCon, dbCon, generator, embedder, storageServer, rabbitmqURL, hub)
 	go func() {
 		workerqueue.StartWorkers(*workerCfg, 5)
 	}()
+	//files processed before the chat have no passages to answer from
+	err = queue.PublishTask(workerqueue.Message{Type: workerqueue.MessageBackfillPassages})
+	if err != nil {
+		log.Printf("cannot queue the passage backfill: %v", err)
+	}
+
+	//permanently remove trashed items after 30 days
+	go server.StartTrashPurger(30*24*time.Hour, time.Hour)
 
 	cfg := api.Config{Server: server, JWTKey: jwtKey, Queue: queue, Hub: hub}
 	log.Println("listening on 8000")
@@ -0,0 +1,359 @@
+package anki
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ErrNewFormat is returned for packages that only carry the zstd compressed collection of Anki 2.1.50+.
+var ErrNewFormat = errors.New(`package uses the new Anki format, export it again with "Support older Anki versions" checked`)
+
+// ErrTooLarge is returned for packages that unpack to more than maxUnpackedSize.
+var ErrTooLarge = errors.New("package is too large to import")
+
+// cap on the collection and media of a package together, once unpacked,
+// so a small zip can't fill the disk
+const maxUnpackedSize = 1 << 30
+
+// card types, also used for the queue a card is in
+const (
+	CardNew        = 0
+	CardLearning   = 1
+	CardReview     = 2
+	CardRelearning = 3
+)
+
+// model types
+const (
+	ModelStandard = 0
+	ModelCloze    = 1
+)
+
+// Package is an unpacked .apkg: the collection database and its media files.
+type Package struct {
+	dir   string
+	db    *sql.DB
+	media map[string]string // file name -> path on disk
+
+	// review due dates count days from here
+	Created time.Time
+	Decks   map[int64]Deck
+	Models  map[int64]Model
+}
+
+type Deck struct {
+	ID   int64  `json:"-"`
+	Name string `json:"name"`
+}
+
+type Field struct {
+	Name string `json:"name"`
+	Ord  int    `json:"ord"`
+}
+
+type Template struct {
+	Name  string `json:"name"`
+	Ord   int    `json:"ord"`
+	Front string `json:"qfmt"`
+	Back  string `json:"afmt"`
+}
+
+type Model struct {
+	ID        int64      `json:"-"`
+	Name      string     `json:"name"`
+	Type      int        `json:"type"`
+	Fields    []Field    `json:"flds"`
+	Templates []Template `json:"tmpls"`
+	CSS       string     `json:"css"`
+}
+
+type Note struct {
+	ID      int64
+	GUID    string
+	ModelID int64
+	Tags    []string
+	Fields  []string
+}
+
+type Card struct {
+	ID     int64
+	NoteID int64
+	DeckID int64
+	Ord    int
+	Type   int
+	Due    int64
+	// days for review cards, negative seconds while learning
+	Interval int
+	// ease in permille, 0 for new cards
+	Factor int
+	Reps   int
+	Lapses int
+}
+
+type Review struct {
+	ID           int64 // epoch milliseconds of the review
+	CardID       int64
+	Ease         int // 1-4, 0 for manual rescheduling
+	Interval     int
+	LastInterval int
+	Factor       int
+	Time         int // milliseconds spent
+	Type         int
+}
+
+// Open unpacks the .apkg at path into a temp dir. Close removes it.
+func Open(path string) (*Package, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid .apkg: %v", err)
+	}
+	defer archive.Close()
+
+	entries := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		entries[f.Name] = f
+	}
+
+	collection := entries["collection.anki21"]
+	if collection == nil {
+		if entries["collection.anki21b"] != nil {
+			return nil, ErrNewFormat
+		}
+		collection = entries["collection.anki2"]
+	}
+	if collection == nil {
+		return nil, fmt.Errorf("not a valid .apkg: no collection")
+	}
+
+	dir, err := os.MkdirTemp("", "apkg-")
+	if err != nil {
+		return nil, err
+	}
+	p := &Package{dir: dir, media: make(map[string]string)}
+	budget := int64(maxUnpackedSize)
+
+	dbPath := filepath.Join(dir, "collection.db")
+	err = extract(collection, dbPath, &budget)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	//media is a json map of the numbered zip entries to file names
+	if f := entries["media"]; f != nil {
+		names, err := readMediaMap(f)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		for num, name := range names {
+			entry := entries[num]
+			if entry == nil || name == "" {
+				continue
+			}
+			//entry names are numbers, never paths
+			mediaPath := filepath.Join(dir, "media-"+filepath.Base(num))
+			err = extract(entry, mediaPath, &budget)
+			if err != nil {
+				p.Close()
+				return nil, err
+			}
+			p.media[name] = mediaPath
+		}
+	}
+
+	p.db, err = sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	err = p.readCol()
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Package) Close() error {
+	if p.db != nil {
+		p.db.Close()
+	}
+	return os.RemoveAll(p.dir)
+}
+
+// MediaPath returns where the media file referenced by name was unpacked.
+func (p *Package) MediaPath(name string) (string, bool) {
+	path, ok := p.media[name]
+	return path, ok
+}
+
+func (p *Package) readCol() error {
+	var crt int64
+	var models, decks string
+	err := p.db.QueryRow("SELECT crt, models, decks FROM col").Scan(&crt, &models, &decks)
+	if err != nil {
+		return fmt.Errorf("cannot read collection: %v", err)
+	}
+	p.Created = time.Unix(crt, 0)
+
+	var byKey map[string]Model
+	err = json.Unmarshal([]byte(models), &byKey)
+	if err != nil {
+		return fmt.Errorf("cannot read note types: %v", err)
+	}
+	p.Models = make(map[int64]Model, len(byKey))
+	for key, m := range byKey {
+		m.ID, _ = strconv.ParseInt(key, 10, 64)
+		p.Models[m.ID] = m
+	}
+
+	var decksByKey map[string]Deck
+	err = json.Unmarshal([]byte(decks), &decksByKey)
+	if err != nil {
+		return fmt.Errorf("cannot read decks: %v", err)
+	}
+	p.Decks = make(map[int64]Deck, len(decksByKey))
+	for key, d := range decksByKey {
+		d.ID, _ = strconv.ParseInt(key, 10, 64)
+		p.Decks[d.ID] = d
+	}
+	return nil
+}
+
+func (p *Package) Notes() (map[int64]Note, error) {
+	rows, err := p.db.Query("SELECT id, guid, mid, tags, flds FROM notes")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read notes: %v", err)
+	}
+	defer rows.Close()
+
+	notes := make(map[int64]Note)
+	for rows.Next() {
+		var n Note
+		var tags, fields string
+		err = rows.Scan(&n.ID, &n.GUID, &n.ModelID, &tags, &fields)
+		if err != nil {
+			return nil, err
+		}
+		n.Tags = strings.Fields(tags)
+		n.Fields = strings.Split(fields, "\x1f")
+		notes[n.ID] = n
+	}
+	return notes, rows.Err()
+}
+
+// Cards lists the cards in deck order. Cards sitting in a filtered deck are
+// reported in their home deck with their original due.
+func (p *Package) Cards() ([]Card, error) {
+	rows, err := p.db.Query("SELECT id, nid, did, ord, type, due, ivl, factor, reps, lapses, odid, odue FROM cards ORDER BY did, due, id")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read cards: %v", err)
+	}
+	defer rows.Close()
+
+	var cards []Card
+	for rows.Next() {
+		var c Card
+		var odid, odue int64
+		err = rows.Scan(&c.ID, &c.NoteID, &c.DeckID, &c.Ord, &c.Type, &c.Due, &c.Interval, &c.Factor, &c.Reps, &c.Lapses, &odid, &odue)
+		if err != nil {
+			return nil, err
+		}
+		if odid != 0 {
+			c.DeckID, c.Due = odid, odue
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
+
+// Reviews returns the review log grouped by card, oldest first.
+func (p *Package) Reviews() (map[int64][]Review, error) {
+	rows, err := p.db.Query("SELECT id, cid, ease, ivl, lastIvl, factor, time, type FROM revlog ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("cannot read review log: %v", err)
+	}
+	defer rows.Close()
+
+	reviews := make(map[int64][]Review)
+	for rows.Next() {
+		var r Review
+		err = rows.Scan(&r.ID, &r.CardID, &r.Ease, &r.Interval, &r.LastInterval, &r.Factor, &r.Time, &r.Type)
+		if err != nil {
+			return nil, err
+		}
+		reviews[r.CardID] = append(reviews[r.CardID], r)
+	}
+	return reviews, rows.Err()
+}
+
+// DueDate converts the due field of a card to a time, zero for new cards.
+func (p *Package) DueDate(c Card) time.Time {
+	switch c.Type {
+	case CardReview:
+		return p.Created.AddDate(0, 0, int(c.Due))
+	case CardLearning, CardRelearning:
+		//intraday learning stores a timestamp, interday learning a day number
+		if c.Due > 1_000_000_000 {
+			return time.Unix(c.Due, 0)
+		}
+		return p.Created.AddDate(0, 0, int(c.Due))
+	}
+	return time.Time{}
+}
+
+// extract unpacks f to dest and takes its size off budget. The header sizes
+// can lie, the copy itself is what's limited.
+func extract(f *zip.File, dest string, budget *int64) error {
+	src, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("cannot read %v from package: %v", f.Name, err)
+	}
+	defer src.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(out, io.LimitReader(src, *budget+1))
+	if err != nil {
+		out.Close()
+		return fmt.Errorf("cannot unpack %v: %v", f.Name, err)
+	}
+	if n > *budget {
+		out.Close()
+		return ErrTooLarge
+	}
+	*budget -= n
+	return out.Close()
+}
+
+// the media list only holds file names, even big decks stay far below this
+const maxMediaMapSize = 16 << 20
+
+func readMediaMap(f *zip.File) (map[string]string, error) {
+	src, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var names map[string]string
+	err = json.NewDecoder(io.LimitReader(src, maxMediaMapSize)).Decode(&names)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read media list: %v", err)
+	}
+	return names, nil
+}
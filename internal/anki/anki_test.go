@@ -0,0 +1,246 @@
+package anki
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writePackage exports deck to a temp .apkg and returns its path.
+func writePackage(t *testing.T, deck ExportDeck) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "deck.apkg")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	err = Write(f, deck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeZip writes a zip with the given entries to a temp file.
+func writeZip(t *testing.T, entries map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	archive := zip.NewWriter(f)
+	for name, body := range entries {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, body)
+	}
+	err = archive.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRoundTrip(t *testing.T) {
+	due := time.Now().AddDate(0, 0, 5)
+	deck := ExportDeck{
+		Name: "Biology",
+		Notes: []ExportNote{
+			{GUID: "a", Front: "What is ATP?", Back: "The energy currency of the cell", Tags: []string{"cell", "energy unit"}},
+			{
+				GUID: "b", Front: "Where is DNA?", Back: "In the <b>nucleus</b>",
+				Type: CardReview, Due: due, Interval: 12, Factor: 2300, Reps: 4, Lapses: 1,
+				Reviews: []Review{{ID: 1700000000000, Ease: 3, Interval: 12, LastInterval: 4, Factor: 2300, Time: 5000, Type: 1}},
+			},
+		},
+		Media: []ExportMedia{{Name: "cell.png", Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("png bytes")), nil
+		}}},
+	}
+
+	pkg, err := Open(writePackage(t, deck))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pkg.Close()
+
+	notes, err := pkg.Notes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cards, err := pkg.Cards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 || len(cards) != 2 {
+		t.Fatalf("got %d notes and %d cards, want 2 of each", len(notes), len(cards))
+	}
+
+	byGUID := make(map[string]Card)
+	for _, c := range cards {
+		byGUID[notes[c.NoteID].GUID] = c
+	}
+
+	newCard := byGUID["a"]
+	front, back := pkg.Render(notes[newCard.NoteID], newCard)
+	if front != "What is ATP?" || strings.TrimSpace(back) != "The energy currency of the cell" {
+		t.Errorf("rendered %q / %q", front, back)
+	}
+	if tags := notes[newCard.NoteID].Tags; len(tags) != 2 || tags[1] != "energy_unit" {
+		t.Errorf("tags %q, want [cell energy_unit]", tags)
+	}
+	if newCard.Type != CardNew || !pkg.DueDate(newCard).IsZero() {
+		t.Errorf("new card has type %d and due %v", newCard.Type, pkg.DueDate(newCard))
+	}
+
+	review := byGUID["b"]
+	if review.Type != CardReview || review.Interval != 12 || review.Factor != 2300 || review.Lapses != 1 {
+		t.Errorf("review card %+v lost its schedule", review)
+	}
+	if got := pkg.DueDate(review).Format("2006-01-02"); got != due.UTC().Format("2006-01-02") {
+		t.Errorf("due %v, want %v", got, due.UTC().Format("2006-01-02"))
+	}
+
+	reviews, err := pkg.Reviews()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := reviews[review.ID]; len(r) != 1 || r[0].Ease != 3 || r[0].LastInterval != 4 {
+		t.Errorf("reviews %+v", r)
+	}
+
+	path, ok := pkg.MediaPath("cell.png")
+	if !ok {
+		t.Fatal("media cell.png missing")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "png bytes" {
+		t.Errorf("media holds %q, %v", data, err)
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries map[string]string
+		want    error
+	}{
+		{name: "new format", entries: map[string]string{"collection.anki21b": "zstd"}, want: ErrNewFormat},
+		{name: "no collection", entries: map[string]string{"media": "{}"}},
+		{name: "broken media list", entries: map[string]string{"collection.anki2": "", "media": "{"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, err := Open(writeZip(t, tt.entries))
+			if err == nil {
+				pkg.Close()
+				t.Fatal("expected an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractBudget(t *testing.T) {
+	archive, err := zip.OpenReader(writeZip(t, map[string]string{"1": strings.Repeat("x", 100), "2": strings.Repeat("y", 100)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer archive.Close()
+	entries := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		entries[f.Name] = f
+	}
+
+	dir := t.TempDir()
+	budget := int64(150)
+	err = extract(entries["1"], filepath.Join(dir, "1"), &budget)
+	if err != nil || budget != 50 {
+		t.Fatalf("first entry: %v, %d left", err, budget)
+	}
+	err = extract(entries["2"], filepath.Join(dir, "2"), &budget)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("second entry: got %v, want ErrTooLarge", err)
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	fields := map[string]string{"Front": "Paris", "Back": "<i>capital</i> of France", "Extra": ""}
+	tests := []struct {
+		name     string
+		tmpl     string
+		clozeNum int
+		answer   bool
+		want     string
+	}{
+		{name: "field", tmpl: "{{Front}}", want: "Paris"},
+		{name: "text filter", tmpl: "{{text:Back}}", want: "capital of France"},
+		{name: "filled section", tmpl: "{{#Front}}has {{Front}}{{/Front}}", want: "has Paris"},
+		{name: "empty section", tmpl: "{{#Extra}}extra{{/Extra}}", want: ""},
+		{name: "inverted section", tmpl: "{{^Extra}}no extra{{/Extra}}", want: "no extra"},
+		{name: "type filter", tmpl: "{{type:Front}}", want: ""},
+		{name: "unknown field", tmpl: "{{Missing}}", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderTemplate(tt.tmpl, fields, tt.clozeNum, tt.answer)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderCloze(t *testing.T) {
+	text := "{{c1::Paris}} is the capital of {{c2::France::country}}"
+	tests := []struct {
+		clozeNum int
+		answer   bool
+		want     string
+	}{
+		{clozeNum: 1, want: "[...] is the capital of France"},
+		{clozeNum: 1, answer: true, want: "<b>Paris</b> is the capital of France"},
+		{clozeNum: 2, want: "Paris is the capital of [country]"},
+		{clozeNum: 2, answer: true, want: "Paris is the capital of <b>France</b>"},
+	}
+	for _, tt := range tests {
+		got := renderCloze(text, tt.clozeNum, tt.answer)
+		if got != tt.want {
+			t.Errorf("c%d answer=%v: got %q, want %q", tt.clozeNum, tt.answer, got, tt.want)
+		}
+	}
+}
+
+func TestSounds(t *testing.T) {
+	text, names := Sounds("bonjour [sound:hello.mp3] [sound:b.ogg]")
+	if strings.TrimSpace(text) != "bonjour" || len(names) != 2 || names[0] != "hello.mp3" {
+		t.Errorf("got %q and %q", text, names)
+	}
+}
+
+func TestNoteTags(t *testing.T) {
+	if got := noteTags(nil); got != "" {
+		t.Errorf("no tags gave %q", got)
+	}
+	if got := noteTags([]string{"a", "two words"}); got != " a two_words " {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStripHTML(t *testing.T) {
+	if got := stripHTML("<b>A &amp; B</b> "); got != "A & B" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,359 @@
+package anki
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fixed so packages exported at different times share one note type in Anki
+const exportModelID = 1718900000000
+
+// ExportDeck is what Write packs into an .apkg. Front and Back are HTML.
+type ExportDeck struct {
+	Name  string
+	Notes []ExportNote
+	Media []ExportMedia
+}
+
+type ExportNote struct {
+	GUID  string
+	Front string
+	Back  string
+	Tags  []string
+	// scheduling, one of the Card* types. Due is unused for new cards
+	Type     int
+	Due      time.Time
+	Interval int
+	Factor   int
+	Reps     int
+	Lapses   int
+	Reviews  []Review
+}
+
+// ExportMedia is a file the notes reference by Name.
+type ExportMedia struct {
+	Name string
+	Open func() (io.ReadCloser, error)
+}
+
+const schema = `
+CREATE TABLE col (id integer primary key, crt integer not null, mod integer not null, scm integer not null, ver integer not null, dty integer not null, usn integer not null, ls integer not null, conf text not null, models text not null, decks text not null, dconf text not null, tags text not null);
+CREATE TABLE notes (id integer primary key, guid text not null, mid integer not null, mod integer not null, usn integer not null, tags text not null, flds text not null, sfld integer not null, csum integer not null, flags integer not null, data text not null);
+CREATE TABLE cards (id integer primary key, nid integer not null, did integer not null, ord integer not null, mod integer not null, usn integer not null, type integer not null, queue integer not null, due integer not null, ivl integer not null, factor integer not null, reps integer not null, lapses integer not null, left integer not null, odue integer not null, odid integer not null, flags integer not null, data text not null);
+CREATE TABLE revlog (id integer primary key, cid integer not null, usn integer not null, ease integer not null, ivl integer not null, lastIvl integer not null, factor integer not null, time integer not null, type integer not null);
+CREATE TABLE graves (usn integer not null, oid integer not null, type integer not null);
+CREATE INDEX ix_notes_usn ON notes (usn);
+CREATE INDEX ix_cards_usn ON cards (usn);
+CREATE INDEX ix_revlog_usn ON revlog (usn);
+CREATE INDEX ix_cards_nid ON cards (nid);
+CREATE INDEX ix_cards_sched ON cards (did, queue, due);
+CREATE INDEX ix_revlog_cid ON revlog (cid);
+CREATE INDEX ix_notes_csum ON notes (csum);
+`
+
+// Write packs deck as an .apkg with a Basic (Front/Back) note type. Notes keep
+// their scheduling state and review log.
+func Write(w io.Writer, deck ExportDeck) error {
+	tmp, err := os.CreateTemp("", "export-*.anki2")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	err = writeCollection(tmp.Name(), deck)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	err = addFile(archive, "collection.anki2", tmp.Name())
+	if err != nil {
+		return err
+	}
+
+	//media entries are numbered, the media map gives their names
+	names := make(map[string]string, len(deck.Media))
+	for i, m := range deck.Media {
+		num := strconv.Itoa(i)
+		names[num] = m.Name
+		err = addMedia(archive, num, m)
+		if err != nil {
+			return err
+		}
+	}
+	mediaMap, err := json.Marshal(names)
+	if err != nil {
+		return err
+	}
+	entry, err := archive.Create("media")
+	if err != nil {
+		return err
+	}
+	_, err = entry.Write(mediaMap)
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func writeCollection(path string, deck ExportDeck) error {
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	_, err = db.Exec(schema)
+	if err != nil {
+		return fmt.Errorf("cannot create collection: %v", err)
+	}
+
+	now := time.Now()
+	created := collectionStart(now, deck.Notes)
+	//ids are epoch milliseconds in Anki, the deck gets the first one
+	base := now.UnixMilli()
+	deckID := base
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	conf, models, decks, dconf, err := colJSON(now, deckID, deck)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')",
+		created.Unix(), base, base, conf, models, decks, dconf)
+	if err != nil {
+		return fmt.Errorf("cannot write collection: %v", err)
+	}
+
+	revlogIDs := make(map[int64]bool)
+	for i, n := range deck.Notes {
+		id := base + int64(i)
+		fields := n.Front + "\x1f" + n.Back
+		sortField := stripHTML(n.Front)
+		_, err = tx.Exec("INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')",
+			id, n.GUID, exportModelID, now.Unix(), noteTags(n.Tags), fields, sortField, checksum(sortField))
+		if err != nil {
+			return fmt.Errorf("cannot write note: %v", err)
+		}
+
+		queue, due, left, interval, factor := cardSchedule(n, i, created, now)
+		_, err = tx.Exec("INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, ?, ?, ?, ?, ?, ?, ?, ?, 0, 0, 0, '')",
+			id, id, deckID, now.Unix(), n.Type, queue, due, interval, factor, n.Reps, n.Lapses, left)
+		if err != nil {
+			return fmt.Errorf("cannot write card: %v", err)
+		}
+
+		for _, r := range n.Reviews {
+			//revlog ids are the review time and must be unique
+			for revlogIDs[r.ID] {
+				r.ID++
+			}
+			revlogIDs[r.ID] = true
+			_, err = tx.Exec("INSERT INTO revlog VALUES (?, ?, -1, ?, ?, ?, ?, ?, ?)",
+				r.ID, id, r.Ease, r.Interval, r.LastInterval, r.Factor, r.Time, r.Type)
+			if err != nil {
+				return fmt.Errorf("cannot write review: %v", err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// collectionStart is the day review due dates count from. It goes back to the
+// earliest due date so overdue cards keep positive day numbers.
+func collectionStart(now time.Time, notes []ExportNote) time.Time {
+	start := now
+	for _, n := range notes {
+		if n.Type == CardReview && !n.Due.IsZero() && n.Due.Before(start) {
+			start = n.Due
+		}
+	}
+	y, m, d := start.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// cardSchedule maps the scheduling state of a note to the queue, due, left,
+// ivl and factor columns of its card.
+func cardSchedule(n ExportNote, pos int, created, now time.Time) (queue int, due int64, left int, interval int, factor int) {
+	factor = n.Factor
+	if factor == 0 {
+		factor = 2500
+	}
+	switch n.Type {
+	case CardLearning, CardRelearning:
+		due = n.Due.Unix()
+		if n.Due.IsZero() {
+			due = now.Unix()
+		}
+		//one step left, due today
+		return CardLearning, due, 1001, n.Interval, factor
+	case CardReview:
+		dueDate := n.Due
+		if dueDate.IsZero() {
+			dueDate = now
+		}
+		due = int64(dueDate.Sub(created).Hours() / 24)
+		return CardReview, due, 0, max(n.Interval, 1), factor
+	}
+	//new cards are due in insertion order
+	return CardNew, int64(pos + 1), 0, 0, 0
+}
+
+func colJSON(now time.Time, deckID int64, deck ExportDeck) (conf, models, decks, dconf string, err error) {
+	mod := now.Unix()
+	confMap := map[string]any{
+		"nextPos":       len(deck.Notes) + 1,
+		"estTimes":      true,
+		"activeDecks":   []int64{deckID},
+		"sortType":      "noteFld",
+		"timeLim":       0,
+		"sortBackwards": false,
+		"addToCur":      true,
+		"curDeck":       deckID,
+		"newBury":       true,
+		"newSpread":     0,
+		"dueCounts":     true,
+		"curModel":      strconv.Itoa(exportModelID),
+		"collapseTime":  1200,
+	}
+
+	field := func(name string, ord int) map[string]any {
+		return map[string]any{"name": name, "ord": ord, "sticky": false, "rtl": false, "font": "Arial", "size": 20, "media": []string{}}
+	}
+	modelsMap := map[string]any{
+		strconv.Itoa(exportModelID): map[string]any{
+			"id":    exportModelID,
+			"name":  "CueMind Basic",
+			"type":  ModelStandard,
+			"mod":   mod,
+			"usn":   -1,
+			"sortf": 0,
+			"did":   deckID,
+			"flds":  []any{field("Front", 0), field("Back", 1)},
+			"tmpls": []any{map[string]any{
+				"name":  "Card 1",
+				"ord":   0,
+				"qfmt":  "{{Front}}",
+				"afmt":  "{{FrontSide}}\n\n<hr id=answer>\n\n{{Back}}",
+				"did":   nil,
+				"bqfmt": "",
+				"bafmt": "",
+			}},
+			"css":       ".card {\n font-family: arial;\n font-size: 20px;\n text-align: center;\n color: black;\n background-color: white;\n}\nimg { max-width: 100%; }\n",
+			"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage[utf8]{inputenc}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+			"latexPost": "\\end{document}",
+			"tags":      []string{},
+			"vers":      []any{},
+			"req":       []any{[]any{0, "all", []int{0}}},
+		},
+	}
+
+	newDeck := func(id int64, name string) map[string]any {
+		return map[string]any{
+			"id": id, "name": name, "desc": "", "mod": mod, "usn": -1, "collapsed": false,
+			"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+			"dyn": 0, "conf": 1, "extendNew": 10, "extendRev": 50,
+		}
+	}
+	decksMap := map[string]any{
+		"1":                           newDeck(1, "Default"),
+		strconv.FormatInt(deckID, 10): newDeck(deckID, deck.Name),
+	}
+
+	dconfMap := map[string]any{
+		"1": map[string]any{
+			"id": 1, "name": "Default", "mod": 0, "usn": 0, "dyn": false,
+			"maxTaken": 60, "timer": 0, "autoplay": true, "replayq": true,
+			"new": map[string]any{
+				"delays": []float64{1, 10}, "ints": []int{1, 4, 7}, "initialFactor": 2500,
+				"order": 1, "perDay": 20, "separate": true, "bury": true,
+			},
+			"lapse": map[string]any{
+				"delays": []float64{10}, "mult": 0, "minInt": 1, "leechFails": 8, "leechAction": 0,
+			},
+			"rev": map[string]any{
+				"perDay": 200, "ease4": 1.3, "fuzz": 0.05, "maxIvl": 36500, "ivlFct": 1, "bury": true, "minSpace": 1,
+			},
+		},
+	}
+
+	var out [4][]byte
+	for i, v := range []any{confMap, modelsMap, decksMap, dconfMap} {
+		out[i], err = json.Marshal(v)
+		if err != nil {
+			return "", "", "", "", err
+		}
+	}
+	return string(out[0]), string(out[1]), string(out[2]), string(out[3]), nil
+}
+
+// noteTags formats tags the way Anki stores them, space separated and padded.
+func noteTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	cleaned := make([]string, len(tags))
+	for i, t := range tags {
+		cleaned[i] = strings.Join(strings.Fields(t), "_")
+	}
+	return " " + strings.Join(cleaned, " ") + " "
+}
+
+// checksum is the duplicate check Anki keeps on notes: the first 8 hex digits
+// of the sha1 of the sort field.
+func checksum(sortField string) int64 {
+	sum := sha1.Sum([]byte(sortField))
+	n, _ := strconv.ParseInt(hex.EncodeToString(sum[:4]), 16, 64)
+	return n
+}
+
+func stripHTML(s string) string {
+	return strings.TrimSpace(html.UnescapeString(tagRe.ReplaceAllString(s, "")))
+}
+
+func addFile(archive *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	entry, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
+
+func addMedia(archive *zip.Writer, num string, m ExportMedia) error {
+	src, err := m.Open()
+	if err != nil {
+		return fmt.Errorf("cannot read media %v: %v", m.Name, err)
+	}
+	defer src.Close()
+	entry, err := archive.Create(num)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, src)
+	if err != nil {
+		return fmt.Errorf("cannot pack media %v: %v", m.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package anki
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	answerRule = regexp.MustCompile(`(?i)<hr\s+id=["']?answer["']?\s*/?>`)
+	section    = regexp.MustCompile(`\{\{([#^])\s*([^}]+?)\s*\}\}`)
+	fieldRef   = regexp.MustCompile(`\{\{([^}]+)\}\}`)
+	cloze      = regexp.MustCompile(`(?s)\{\{c(\d+)::(.*?)(?:::(.*?))?\}\}`)
+	tagRe      = regexp.MustCompile(`<[^>]*>`)
+	sound      = regexp.MustCompile(`\[sound:([^\]]+)\]`)
+)
+
+// Render returns the question and answer HTML of a card. The answer leaves out
+// the question Anki repeats above the answer line.
+func (p *Package) Render(note Note, card Card) (front, back string) {
+	model, ok := p.Models[note.ModelID]
+	if !ok {
+		return "", ""
+	}
+
+	fields := make(map[string]string, len(model.Fields)+2)
+	for _, f := range model.Fields {
+		if f.Ord < len(note.Fields) {
+			fields[f.Name] = note.Fields[f.Ord]
+		}
+	}
+	fields["Tags"] = strings.Join(note.Tags, " ")
+	fields["Deck"] = p.Decks[card.DeckID].Name
+
+	var tmpl Template
+	clozeNum := 0
+	if model.Type == ModelCloze {
+		//cloze notes have one template, the card ord picks the deletion
+		if len(model.Templates) > 0 {
+			tmpl = model.Templates[0]
+		}
+		clozeNum = card.Ord + 1
+	} else {
+		for _, t := range model.Templates {
+			if t.Ord == card.Ord {
+				tmpl = t
+			}
+		}
+	}
+
+	answer := tmpl.Back
+	if loc := answerRule.FindStringIndex(answer); loc != nil {
+		answer = answer[loc[1]:]
+	}
+	answer = strings.ReplaceAll(answer, "{{FrontSide}}", "")
+
+	front = renderTemplate(tmpl.Front, fields, clozeNum, false)
+	back = renderTemplate(answer, fields, clozeNum, true)
+	return front, back
+}
+
+// Sounds removes [sound:...] tags from text and returns the referenced file names.
+func Sounds(text string) (string, []string) {
+	var names []string
+	for _, m := range sound.FindAllStringSubmatch(text, -1) {
+		names = append(names, m[1])
+	}
+	return sound.ReplaceAllString(text, ""), names
+}
+
+// renderTemplate fills a card template with the note fields. It covers field
+// references, conditional sections and the cloze, text and type filters.
+func renderTemplate(tmpl string, fields map[string]string, clozeNum int, answer bool) string {
+	tmpl = renderSections(tmpl, fields)
+
+	return fieldRef.ReplaceAllStringFunc(tmpl, func(ref string) string {
+		parts := strings.Split(strings.TrimSpace(ref[2:len(ref)-2]), ":")
+		name := strings.TrimSpace(parts[len(parts)-1])
+		filters := parts[:len(parts)-1]
+
+		value := fields[name]
+		for _, filter := range filters {
+			switch strings.TrimSpace(filter) {
+			case "cloze":
+				value = renderCloze(value, clozeNum, answer)
+			case "text":
+				value = html.EscapeString(html.UnescapeString(tagRe.ReplaceAllString(value, "")))
+			case "type":
+				//type-in answer box, nothing to show outside Anki
+				value = ""
+			default:
+				if strings.HasPrefix(strings.TrimSpace(filter), "tts") {
+					value = ""
+				}
+			}
+		}
+		return value
+	})
+}
+
+// renderSections keeps {{#Field}}..{{/Field}} when the field has content and {{^Field}}..{{/Field}} when it doesn't.
+func renderSections(tmpl string, fields map[string]string) string {
+	for {
+		loc := section.FindStringSubmatchIndex(tmpl)
+		if loc == nil {
+			return tmpl
+		}
+		kind, name := tmpl[loc[2]:loc[3]], tmpl[loc[4]:loc[5]]
+		closing := "{{/" + name + "}}"
+		end := strings.Index(tmpl[loc[1]:], closing)
+		if end < 0 {
+			//unbalanced, drop the opening tag
+			tmpl = tmpl[:loc[0]] + tmpl[loc[1]:]
+			continue
+		}
+		inner := tmpl[loc[1] : loc[1]+end]
+
+		filled := strings.TrimSpace(tagRe.ReplaceAllString(fields[name], "")) != ""
+		if filled != (kind == "#") {
+			inner = ""
+		}
+		tmpl = tmpl[:loc[0]] + inner + tmpl[loc[1]+end+len(closing):]
+	}
+}
+
+func renderCloze(text string, clozeNum int, answer bool) string {
+	return cloze.ReplaceAllStringFunc(text, func(m string) string {
+		match := cloze.FindStringSubmatch(m)
+		num, _ := strconv.Atoi(match[1])
+		if num != clozeNum {
+			return match[2]
+		}
+		if answer {
+			return "<b>" + match[2] + "</b>"
+		}
+		if match[3] != "" {
+			return "[" + match[3] + "]"
+		}
+		return "[...]"
+	})
+}
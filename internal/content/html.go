@@ -0,0 +1,189 @@
+package content
+
+import (
+	"regexp"
+	"strings"
+
+	nethtml "golang.org/x/net/html"
+)
+
+// FromHTML converts card HTML, as Anki stores it, to the markdown cards are saved as.
+// MathJax \(..\) and \[..\] become $..$ and $$..$$. Images are kept when media
+// returns a reference for their file name, other markup is reduced to its text.
+func FromHTML(src string, media func(name string) string) string {
+	var out strings.Builder
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(src))
+
+	var skip int   // inside script or style
+	var inCode int // inside code or pre, text is not escaped
+	for {
+		tt := tokenizer.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+
+		switch tt {
+		case nethtml.TextToken:
+			if skip > 0 {
+				continue
+			}
+			if inCode > 0 {
+				out.WriteString(token.Data)
+			} else {
+				out.WriteString(escapeMarkdown(token.Data))
+			}
+
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			switch token.Data {
+			case "script", "style":
+				if tt == nethtml.StartTagToken {
+					skip++
+				}
+			case "br":
+				out.WriteString("\n")
+			case "div", "p", "tr", "h1", "h2", "h3", "h4", "h5", "h6":
+				out.WriteString("\n")
+			case "hr":
+				out.WriteString("\n\n---\n\n")
+			case "li":
+				out.WriteString("\n- ")
+			case "b", "strong":
+				out.WriteString("**")
+			case "i", "em":
+				out.WriteString("*")
+			case "code":
+				inCode++
+				out.WriteString("`")
+			case "pre":
+				inCode++
+				out.WriteString("\n```\n")
+			case "img":
+				if ref := media(attr(token, "src")); ref != "" {
+					out.WriteString("![" + attr(token, "alt") + "](" + ref + ")")
+				}
+			}
+
+		case nethtml.EndTagToken:
+			switch token.Data {
+			case "script", "style":
+				if skip > 0 {
+					skip--
+				}
+			case "div", "p", "tr", "ul", "ol", "h1", "h2", "h3", "h4", "h5", "h6":
+				out.WriteString("\n")
+			case "b", "strong":
+				out.WriteString("**")
+			case "i", "em":
+				out.WriteString("*")
+			case "code":
+				inCode = max(inCode-1, 0)
+				out.WriteString("`")
+			case "pre":
+				inCode = max(inCode-1, 0)
+				out.WriteString("\n```\n")
+			}
+		}
+	}
+	return tidyLines(out.String())
+}
+
+func attr(token nethtml.Token, key string) string {
+	for _, a := range token.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+var mathJax = regexp.MustCompile(`(?s)\\\((.+?)\\\)|\\\[(.+?)\\\]`)
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`,
+	"#", `\#`, "$", `\$`, "<", "&lt;", ">", "&gt;",
+)
+
+// escapeMarkdown escapes text so markdown shows it literally, except for MathJax
+// formulas which are rewritten to dollar math and left as they are.
+func escapeMarkdown(text string) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range mathJax.FindAllStringSubmatchIndex(text, -1) {
+		out.WriteString(markdownEscaper.Replace(text[last:m[0]]))
+		if m[2] >= 0 {
+			out.WriteString("$" + strings.TrimSpace(text[m[2]:m[3]]) + "$")
+		} else {
+			out.WriteString("$$" + strings.TrimSpace(text[m[4]:m[5]]) + "$$")
+		}
+		last = m[1]
+	}
+	out.WriteString(markdownEscaper.Replace(text[last:]))
+	return out.String()
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+func tidyLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	text = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
+
+// StripHTML returns the text of an HTML fragment.
+func StripHTML(src string) string {
+	var out strings.Builder
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(src))
+	for {
+		tt := tokenizer.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		if tt == nethtml.TextToken {
+			out.Write(tokenizer.Text())
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// blocks that end a line when HTML is read back as text
+var textBreaks = map[string]bool{"p": true, "li": true, "pre": true, "tr": true, "blockquote": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true}
+
+// htmlText is StripHTML keeping line breaks between blocks and bullets on list items.
+func htmlText(src string) string {
+	var out strings.Builder
+	tokenizer := nethtml.NewTokenizer(strings.NewReader(src))
+	for {
+		tt := tokenizer.Next()
+		if tt == nethtml.ErrorToken {
+			break
+		}
+		name, _ := tokenizer.TagName()
+		switch tt {
+		case nethtml.TextToken:
+			out.Write(tokenizer.Text())
+		case nethtml.StartTagToken, nethtml.SelfClosingTagToken:
+			switch string(name) {
+			case "br":
+				out.WriteString("\n")
+			case "li":
+				out.WriteString("• ")
+			}
+		case nethtml.EndTagToken:
+			if textBreaks[string(name)] {
+				out.WriteString("\n")
+			}
+		}
+	}
+	lines := strings.Split(out.String(), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimRight(line, " \t"); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,92 @@
+package content
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MediaScheme prefixes media IDs in card text, e.g. ![diagram](media:<id>).
+const MediaScheme = "media:"
+
+var mediaRef = regexp.MustCompile(`media:([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})`)
+
+// ResolveMedia swaps media references for the URLs in urls, keyed by media ID.
+// Unknown references are left as they are and dropped by the sanitizer.
+func ResolveMedia(text string, urls map[string]string) string {
+	if len(urls) == 0 {
+		return text
+	}
+	return mediaRef.ReplaceAllStringFunc(text, func(ref string) string {
+		if url, ok := urls[ref[len(MediaScheme):]]; ok {
+			return url
+		}
+		return ref
+	})
+}
+
+// MediaIDs returns the media IDs text references, in order, repeats included.
+func MediaIDs(text string) []string {
+	var ids []string
+	for _, m := range mediaRef.FindAllStringSubmatch(text, -1) {
+		ids = append(ids, m[1])
+	}
+	return ids
+}
+
+var mediaKinds = map[string]string{
+	"image/png":  "image",
+	"image/jpeg": "image",
+	"image/gif":  "image",
+	"image/webp": "image",
+	"audio/mpeg": "audio",
+	"audio/ogg":  "audio",
+	"audio/wav":  "audio",
+	"audio/webm": "audio",
+	"audio/mp4":  "audio",
+}
+
+var mediaExtensions = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".wav":  "audio/wav",
+	".webm": "audio/webm",
+	".m4a":  "audio/mp4",
+}
+
+// MediaExtension is the file extension used for a media content type when exporting.
+func MediaExtension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "audio/mpeg":
+		return ".mp3"
+	case "audio/ogg":
+		return ".ogg"
+	case "audio/mp4":
+		return ".m4a"
+	}
+	for ext, t := range mediaExtensions {
+		if t == contentType {
+			return ext
+		}
+	}
+	return ""
+}
+
+// MediaType guesses the content type of a media file from its name, empty when cards can't attach it.
+func MediaType(name string) string {
+	return mediaExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// MediaKind returns image or audio for the content types cards can attach.
+func MediaKind(contentType string) (string, bool) {
+	kind, ok := mediaKinds[contentType]
+	return kind, ok
+}
@@ -0,0 +1,173 @@
+package content
+
+import (
+	"cmp"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ![[image.png]] embeds, nothing to show on a card
+	wikiEmbed = regexp.MustCompile(`!\[\[[^\]]*\]\]`)
+	// [[Note]] and [[Note|alias]] links keep their text
+	wikiLink      = regexp.MustCompile(`\[\[([^\]|]*)(?:\|([^\]]*))?\]\]`)
+	questionLine  = regexp.MustCompile(`(?i)^q:\s*`)
+	answerLine    = regexp.MustCompile(`(?i)^a:\s*`)
+	fencedCodeRow = regexp.MustCompile("^\\s*(```|~~~)")
+	listMarker    = regexp.MustCompile(`^\s*([-*+]|\d+\.)\s+`)
+	// ^block-id closing a line, what Obsidian links a paragraph or list item by
+	blockID = regexp.MustCompile(`(?:^|\s)\^([A-Za-z0-9-]+)\s*$`)
+)
+
+// Note is a markdown note of an Obsidian style vault.
+type Note struct {
+	// id or uid from the frontmatter, empty when it has none
+	ID   string
+	Tags []string
+	Body string
+}
+
+// ParseNote splits off the YAML frontmatter and reads the id and tags from it.
+// Only the flat key: value and list forms Obsidian writes are understood.
+func ParseNote(src string) Note {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	note := Note{Body: src}
+
+	if !strings.HasPrefix(src, "---\n") {
+		return note
+	}
+	end := strings.Index(src[4:], "\n---")
+	if end < 0 {
+		return note
+	}
+	frontmatter := src[4 : 4+end]
+	note.Body = strings.TrimPrefix(src[4+end+4:], "\n")
+
+	key := ""
+	for _, line := range strings.Split(frontmatter, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if item, ok := strings.CutPrefix(trimmed, "- "); ok && key != "" && line != trimmed {
+			if key == "tags" {
+				note.Tags = append(note.Tags, unquote(item))
+			}
+			continue
+		}
+		name, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "id", "uid":
+			if note.ID == "" {
+				note.ID = unquote(value)
+			}
+		case "tags", "tag":
+			key = "tags"
+			value = strings.Trim(value, "[]")
+			for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+				note.Tags = append(note.Tags, unquote(tag))
+			}
+		}
+	}
+	for i := range note.Tags {
+		note.Tags[i] = strings.TrimPrefix(note.Tags[i], "#")
+	}
+	return note
+}
+
+// NoteCards finds the cards written into a note: Q:/A: blocks, where the answer
+// runs to the next blank line, and term :: definition lines. Code blocks are skipped.
+// A ^block id on any line of a card is taken off the text and kept as its BlockID.
+func NoteCards(body string) []Pair {
+	var pairs []Pair
+	var question, answer []string
+	var id string
+	inAnswer, inCode := false, false
+
+	flush := func() {
+		if len(question) > 0 && len(answer) > 0 {
+			pairs = append(pairs, Pair{
+				Entry:      len(pairs) + 1,
+				Term:       noteText(strings.Join(question, "\n")),
+				Definition: noteText(strings.Join(answer, "\n")),
+				BlockID:    id,
+			})
+		}
+		question, answer, id, inAnswer = nil, nil, "", false
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		if fencedCodeRow.MatchString(line) {
+			inCode = !inCode
+		}
+		var lineID string
+		if !inCode {
+			line, lineID = cutBlockID(line)
+		}
+		switch {
+		case inCode && !inAnswer:
+			continue
+		case questionLine.MatchString(line):
+			flush()
+			question = []string{questionLine.ReplaceAllString(line, "")}
+			id = lineID
+		case len(question) > 0 && answerLine.MatchString(line):
+			inAnswer = true
+			answer = []string{answerLine.ReplaceAllString(line, "")}
+			id = cmp.Or(id, lineID)
+		case inAnswer:
+			//an id on a line of its own right after the answer still belongs to it
+			id = cmp.Or(id, lineID)
+			if strings.TrimSpace(line) == "" && !inCode {
+				flush()
+				continue
+			}
+			answer = append(answer, line)
+		case len(question) > 0:
+			id = cmp.Or(id, lineID)
+			//a question nobody answered
+			if strings.TrimSpace(line) == "" {
+				flush()
+				continue
+			}
+			question = append(question, line)
+		default:
+			term, definition, ok := strings.Cut(listMarker.ReplaceAllString(line, ""), "::")
+			//::: marks a reversible card in Obsidian, both read the same here
+			term, definition = noteText(term), noteText(strings.TrimPrefix(definition, ":"))
+			if ok && term != "" && definition != "" {
+				pairs = append(pairs, Pair{Entry: len(pairs) + 1, Term: term, Definition: definition, BlockID: lineID})
+			}
+		}
+	}
+	flush()
+	return pairs
+}
+
+// cutBlockID takes a closing ^block id off line and returns it separately.
+func cutBlockID(line string) (string, string) {
+	m := blockID.FindStringSubmatchIndex(line)
+	if m == nil {
+		return line, ""
+	}
+	return line[:m[0]], line[m[2]:m[3]]
+}
+
+// noteText drops wiki embeds and turns wiki links into their text.
+func noteText(text string) string {
+	text = wikiEmbed.ReplaceAllString(text, "")
+	text = wikiLink.ReplaceAllStringFunc(text, func(link string) string {
+		m := wikiLink.FindStringSubmatch(link)
+		if m[2] != "" {
+			return m[2]
+		}
+		return m[1]
+	})
+	return strings.TrimSpace(text)
+}
+
+func unquote(s string) string {
+	return strings.Trim(strings.TrimSpace(s), `"'`)
+}
@@ -0,0 +1,77 @@
+package content
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNote(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want Note
+	}{
+		{name: "no frontmatter", src: "just text", want: Note{Body: "just text"}},
+		{
+			name: "inline tags", src: "---\nid: \"n-1\"\ntags: [bio, #cell]\n---\nbody\r\n",
+			want: Note{ID: "n-1", Tags: []string{"bio", "cell"}, Body: "body\n"},
+		},
+		{
+			name: "tag list and uid", src: "---\nuid: abc\ntags:\n  - one\n  - 'two'\nother: x\n---\nbody",
+			want: Note{ID: "abc", Tags: []string{"one", "two"}, Body: "body"},
+		},
+		{name: "unclosed frontmatter", src: "---\nid: x\nbody", want: Note{Body: "---\nid: x\nbody"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseNote(tt.src)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoteCards(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Pair
+	}{
+		{
+			name: "question and answer", body: "Q: What is ATP?\nA: The energy\ncurrency\n\nText after.",
+			want: []Pair{{Entry: 1, Term: "What is ATP?", Definition: "The energy\ncurrency"}},
+		},
+		{
+			name: "term lines", body: "- dog :: Hund\n1. cat ::: Katze\nnot a card",
+			want: []Pair{{Entry: 1, Term: "dog", Definition: "Hund"}, {Entry: 2, Term: "cat", Definition: "Katze"}},
+		},
+		{
+			name: "wiki links", body: "[[Mitochondria|mito]] :: ![[cell.png]] powerhouse of the [[Cell]]",
+			want: []Pair{{Entry: 1, Term: "mito", Definition: "powerhouse of the Cell"}},
+		},
+		{name: "unanswered question", body: "Q: Why?\n\nA: too late"},
+		{name: "code skipped", body: "```\nx :: y\n```"},
+		{
+			name: "block ids", body: "Q: First? ^q1\nA: one\n\nQ: Second?\nA: two\n^q-2\n\n- term :: def ^t3\n- plain :: card",
+			want: []Pair{
+				{Entry: 1, Term: "First?", Definition: "one", BlockID: "q1"},
+				{Entry: 2, Term: "Second?", Definition: "two", BlockID: "q-2"},
+				{Entry: 3, Term: "term", Definition: "def", BlockID: "t3"},
+				{Entry: 4, Term: "plain", Definition: "card"},
+			},
+		},
+		{
+			name: "caret inside text", body: "x^2 :: squared ^sq",
+			want: []Pair{{Entry: 1, Term: "x^2", Definition: "squared", BlockID: "sq"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NoteCards(tt.body)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package content
+
+import (
+	"strings"
+)
+
+// separator names as Quizlet offers them in its export dialog
+var separatorNames = map[string]string{
+	"tab":       "\t",
+	"comma":     ",",
+	"semicolon": ";",
+	"newline":   "\n",
+	"dash":      " - ",
+}
+
+// Pair is one term and definition of a pasted list. Entry counts from 1.
+type Pair struct {
+	Entry      int
+	Term       string
+	Definition string
+	// Obsidian ^block id of a card written in a note, empty elsewhere
+	BlockID string
+}
+
+// Separator resolves a separator name or returns sep itself as a custom separator.
+// Empty sep gives def.
+func Separator(sep, def string) string {
+	if sep == "" {
+		return def
+	}
+	if named, ok := separatorNames[strings.ToLower(sep)]; ok {
+		return named
+	}
+	return sep
+}
+
+// ParsePairs splits pasted text into cards on cardSep and each card into term
+// and definition on the first termSep, the format Quizlet exports. Entries
+// without termSep come back with an empty definition.
+func ParsePairs(text, termSep, cardSep string) []Pair {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var pairs []Pair
+	for i, entry := range strings.Split(text, cardSep) {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		term, definition, _ := strings.Cut(entry, termSep)
+		pairs = append(pairs, Pair{
+			Entry:      i + 1,
+			Term:       strings.TrimSpace(term),
+			Definition: strings.TrimSpace(definition),
+		})
+	}
+	return pairs
+}
@@ -0,0 +1,62 @@
+package content
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePairs(t *testing.T) {
+	tests := []struct {
+		name             string
+		text             string
+		termSep, cardSep string
+		want             []Pair
+	}{
+		{
+			name: "quizlet tabs", text: "dog\tHund\r\ncat\tKatze\n", termSep: "\t", cardSep: "\n",
+			want: []Pair{{Entry: 1, Term: "dog", Definition: "Hund"}, {Entry: 2, Term: "cat", Definition: "Katze"}},
+		},
+		{
+			name: "blank entries keep numbering", text: "a - 1\n\nb - 2", termSep: " - ", cardSep: "\n",
+			want: []Pair{{Entry: 1, Term: "a", Definition: "1"}, {Entry: 3, Term: "b", Definition: "2"}},
+		},
+		{
+			name: "first separator only", text: "a,b,c;d,e", termSep: ",", cardSep: ";",
+			want: []Pair{{Entry: 1, Term: "a", Definition: "b,c"}, {Entry: 2, Term: "d", Definition: "e"}},
+		},
+		{
+			name: "no separator", text: "lonely", termSep: "\t", cardSep: "\n",
+			want: []Pair{{Entry: 1, Term: "lonely", Definition: ""}},
+		},
+		{name: "empty", text: " \n ", termSep: "\t", cardSep: "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParsePairs(tt.text, tt.termSep, tt.cardSep)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeparator(t *testing.T) {
+	tests := []struct{ sep, def, want string }{
+		{sep: "", def: "\t", want: "\t"},
+		{sep: "Comma", def: "\t", want: ","},
+		{sep: "dash", def: "\t", want: " - "},
+		{sep: "|", def: "\t", want: "|"},
+	}
+	for _, tt := range tests {
+		if got := Separator(tt.sep, tt.def); got != tt.want {
+			t.Errorf("Separator(%q) = %q, want %q", tt.sep, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got := NormalizeTags([]string{" Bio ", "bio", "", "Cell"})
+	if !reflect.DeepEqual(got, []string{"bio", "cell"}) {
+		t.Errorf("got %q", got)
+	}
+}
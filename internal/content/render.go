@@ -0,0 +1,245 @@
+package content
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+)
+
+func ValidFormat(format string) bool {
+	return format == "" || format == FormatPlain || format == FormatMarkdown
+}
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	//raw HTML from the card is dropped, only markdown produces tags
+	goldmark.WithRendererOptions(gmhtml.WithHardWraps()),
+)
+
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	//keep the fenced code language so the client can highlight it
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).OnElements("code")
+	return p
+}
+
+// RenderHTML turns card text into HTML that is safe to inject into the page.
+// Math between $...$ or $$...$$ is kept verbatim in span.math elements
+// for the client to typeset with KaTeX/MathJax.
+func RenderHTML(text, format string) string {
+	return render(text, format, func(tex string, display bool) string {
+		class := "math inline"
+		if display {
+			class = "math display"
+		}
+		return fmt.Sprintf(`<span class="%s">%s</span>`, class, html.EscapeString(tex))
+	})
+}
+
+// RenderMathJax is RenderHTML with math written between MathJax \(..\) and \[..\]
+// delimiters, the way Anki and other flashcard apps expect it.
+func RenderMathJax(text, format string) string {
+	return render(text, format, func(tex string, display bool) string {
+		if display {
+			return `\[` + html.EscapeString(tex) + `\]`
+		}
+		return `\(` + html.EscapeString(tex) + `\)`
+	})
+}
+
+// PlainText turns card text into text for print, markdown formatting is dropped
+// and math is kept as its $...$ source.
+func PlainText(text, format string) string {
+	if format != FormatMarkdown {
+		return strings.TrimSpace(text)
+	}
+	return htmlText(render(text, format, func(tex string, display bool) string {
+		if display {
+			return html.EscapeString("$$" + tex + "$$")
+		}
+		return html.EscapeString("$" + tex + "$")
+	}))
+}
+
+func render(text, format string, math func(tex string, display bool) string) string {
+	if format != FormatMarkdown {
+		return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+	}
+
+	nonce := newNonce(text)
+	text, formulas := extractMath(text, nonce)
+
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(text), &buf); err != nil {
+		return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+	}
+	out := policy.Sanitize(buf.String())
+
+	//formulas go back in after sanitizing, escaped, so they can't smuggle markup
+	for i, f := range formulas {
+		out = strings.Replace(out, placeholder(nonce, i), math(f.tex, f.display), 1)
+	}
+	return out
+}
+
+type formula struct {
+	tex     string
+	display bool
+}
+
+// newNonce returns a random word for the placeholders of one render, one the
+// text doesn't contain so a card can't fake a placeholder.
+func newNonce(text string) string {
+	for {
+		b := make([]byte, 8)
+		rand.Read(b)
+		nonce := hex.EncodeToString(b)
+		if !strings.Contains(text, nonce) {
+			return nonce
+		}
+	}
+}
+
+func placeholder(nonce string, i int) string {
+	return fmt.Sprintf("MATH%s%dX", nonce, i)
+}
+
+// extractMath swaps formulas for placeholders so markdown doesn't read
+// underscores and asterisks inside them as emphasis. Code spans and fenced
+// blocks are left alone, a $ in code is not math.
+func extractMath(text, nonce string) (string, []formula) {
+	var formulas []formula
+	var out strings.Builder
+
+	lines := strings.SplitAfter(text, "\n")
+	var fence string
+	var pending []string // lines inside an open $$ block
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if pending != nil {
+			if strings.HasSuffix(trimmed, "$$") {
+				pending = append(pending, strings.TrimSuffix(trimmed, "$$"))
+				formulas = append(formulas, formula{tex: strings.TrimSpace(strings.Join(pending, "\n")), display: true})
+				out.WriteString(placeholder(nonce, len(formulas)-1) + "\n")
+				pending = nil
+			} else {
+				pending = append(pending, strings.TrimRight(line, "\n"))
+			}
+			continue
+		}
+
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			out.WriteString(line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			out.WriteString(line)
+			continue
+		}
+
+		//multi line display math
+		if strings.HasPrefix(trimmed, "$$") && !strings.Contains(trimmed[2:], "$$") {
+			pending = []string{strings.TrimPrefix(trimmed, "$$")}
+			continue
+		}
+
+		out.WriteString(extractInlineMath(line, nonce, &formulas))
+	}
+	//unclosed $$ block, give the text back untouched
+	if pending != nil {
+		out.WriteString("$$" + strings.Join(pending, "\n"))
+	}
+	return out.String(), formulas
+}
+
+func extractInlineMath(line, nonce string, formulas *[]formula) string {
+	var out strings.Builder
+	for i := 0; i < len(line); {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			out.WriteString(line[i : i+2])
+			i += 2
+		case line[i] == '`':
+			//copy the code span as is
+			ticks := 1
+			for i+ticks < len(line) && line[i+ticks] == '`' {
+				ticks++
+			}
+			end := strings.Index(line[i+ticks:], strings.Repeat("`", ticks))
+			if end < 0 {
+				out.WriteString(line[i:])
+				return out.String()
+			}
+			stop := i + ticks + end + ticks
+			out.WriteString(line[i:stop])
+			i = stop
+		case strings.HasPrefix(line[i:], "$$"):
+			end := strings.Index(line[i+2:], "$$")
+			if end < 0 {
+				out.WriteString(line[i:])
+				return out.String()
+			}
+			*formulas = append(*formulas, formula{tex: strings.TrimSpace(line[i+2 : i+2+end]), display: true})
+			out.WriteString(placeholder(nonce, len(*formulas)-1))
+			i += 2 + end + 2
+		case line[i] == '$':
+			end := closingDollar(line, i)
+			if end < 0 {
+				out.WriteByte('$')
+				i++
+				continue
+			}
+			*formulas = append(*formulas, formula{tex: line[i+1 : end]})
+			out.WriteString(placeholder(nonce, len(*formulas)-1))
+			i = end + 1
+		default:
+			out.WriteByte(line[i])
+			i++
+		}
+	}
+	return out.String()
+}
+
+// closingDollar finds the end of an inline formula opened at start. Like pandoc,
+// the opening $ must not be followed by a space and the closing one must not be
+// preceded by a space or followed by a digit, so "$5 and $10" stays text.
+func closingDollar(line string, start int) int {
+	if start+1 >= len(line) || line[start+1] == ' ' {
+		return -1
+	}
+	for j := start + 1; j < len(line); j++ {
+		if line[j] == '\\' {
+			j++
+			continue
+		}
+		if line[j] != '$' {
+			continue
+		}
+		if line[j-1] == ' ' || (j+1 < len(line) && line[j+1] >= '0' && line[j+1] <= '9') {
+			return -1
+		}
+		return j
+	}
+	return -1
+}
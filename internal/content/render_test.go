@@ -0,0 +1,75 @@
+package content
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		format string
+		want   string
+	}{
+		{name: "plain escapes", text: "a <b>\nc", format: FormatPlain, want: "a &lt;b&gt;<br>c"},
+		{name: "emphasis", text: "*x* and **y**", format: FormatMarkdown, want: "<p><em>x</em> and <strong>y</strong></p>\n"},
+		{name: "raw html dropped", text: "<script>alert(1)</script>", format: FormatMarkdown, want: "\n"},
+		{name: "javascript link", text: "[link](javascript:alert(1))", format: FormatMarkdown, want: "<p>link</p>\n"},
+		{name: "code language kept", text: "```go\nx\n```", format: FormatMarkdown, want: "<pre><code class=\"language-go\">x\n</code></pre>\n"},
+		{
+			name: "inline math", text: "$a_1$ and $b_2$", format: FormatMarkdown,
+			want: "<p><span class=\"math inline\">a_1</span> and <span class=\"math inline\">b_2</span></p>\n",
+		},
+		{name: "display math", text: "$$\nx^2\n$$", format: FormatMarkdown, want: "<p><span class=\"math display\">x^2</span></p>\n"},
+		{name: "markup in math", text: "$<img src=x>$", format: FormatMarkdown, want: "<p><span class=\"math inline\">&lt;img src=x&gt;</span></p>\n"},
+		{name: "prices", text: "$5 and $10", format: FormatMarkdown, want: "<p>$5 and $10</p>\n"},
+		{name: "code span", text: "`$x$`", format: FormatMarkdown, want: "<p><code>$x$</code></p>\n"},
+		{name: "fenced code", text: "```\n$x$\n```", format: FormatMarkdown, want: "<pre><code>$x$\n</code></pre>\n"},
+		{name: "unclosed display", text: "$$unclosed\nfoo", format: FormatMarkdown, want: "<p>$$unclosed<br>\nfoo</p>\n"},
+		{name: "placeholder lookalike", text: "MATH0X $y$", format: FormatMarkdown, want: "<p>MATH0X <span class=\"math inline\">y</span></p>\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RenderHTML(tt.text, tt.format)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMathJax(t *testing.T) {
+	got := RenderMathJax("$x$ $$y$$", FormatMarkdown)
+	if got != "<p>\\(x\\) \\[y\\]</p>\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		text   string
+		format string
+		want   string
+	}{
+		{text: "  **bold**  ", format: FormatPlain, want: "**bold**"},
+		{text: "**bold** $x$", format: FormatMarkdown, want: "bold $x$"},
+		{text: "$$x < y$$", format: FormatMarkdown, want: "$$x < y$$"},
+	}
+	for _, tt := range tests {
+		got := PlainText(tt.text, tt.format)
+		if got != tt.want {
+			t.Errorf("PlainText(%q, %q) = %q, want %q", tt.text, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestNonceNotInText(t *testing.T) {
+	nonce := newNonce("some text")
+	if len(nonce) != 16 || strings.Contains("some text", nonce) {
+		t.Errorf("bad nonce %q", nonce)
+	}
+	if newNonce("") == nonce {
+		t.Error("nonce repeated")
+	}
+}
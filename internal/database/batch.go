@@ -0,0 +1,50 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// CardRow is one card of an InsertCards batch.
+type CardRow struct {
+	ID              uuid.UUID
+	Front           string
+	Back            string
+	DueDate         sql.NullTime
+	FileID          uuid.NullUUID
+	Tags            []string
+	DuplicateOf     uuid.NullUUID
+	SourcePageStart sql.NullInt32
+	SourcePageEnd   sql.NullInt32
+	SourceExcerpt   sql.NullString
+	NoteKey         sql.NullString
+	NoteOrdinal     sql.NullInt32
+	NoteAnchor      sql.NullString
+}
+
+// Add appends row to the batch and returns its id, a new one when row.ID is unset.
+// Ids are made here so callers can refer to the cards without RETURNING.
+func (p *InsertCardsParams) Add(row CardRow) uuid.UUID {
+	if row.ID == uuid.Nil {
+		row.ID = uuid.New()
+	}
+	//tags go as one json array per card, postgres can't unnest ragged arrays
+	tags, _ := json.Marshal(row.Tags)
+
+	p.Ids = append(p.Ids, row.ID)
+	p.Fronts = append(p.Fronts, row.Front)
+	p.Backs = append(p.Backs, row.Back)
+	p.DueDates = append(p.DueDates, row.DueDate)
+	p.FileIds = append(p.FileIds, row.FileID)
+	p.Tags = append(p.Tags, string(tags))
+	p.DuplicateOfs = append(p.DuplicateOfs, row.DuplicateOf)
+	p.PageStarts = append(p.PageStarts, row.SourcePageStart)
+	p.PageEnds = append(p.PageEnds, row.SourcePageEnd)
+	p.Excerpts = append(p.Excerpts, row.SourceExcerpt)
+	p.NoteKeys = append(p.NoteKeys, row.NoteKey)
+	p.NoteOrdinals = append(p.NoteOrdinals, row.NoteOrdinal)
+	p.NoteAnchors = append(p.NoteAnchors, row.NoteAnchor)
+	return row.ID
+}
@@ -7,33 +7,87 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const applyMergeDrafts = `-- name: ApplyMergeDrafts :many
+WITH applied AS (
+    UPDATE cards SET back = drafts.back
+    FROM cards drafts
+    JOIN card_merge_drafts ON card_merge_drafts.card_id = drafts.id
+    WHERE cards.id = drafts.duplicate_of AND drafts.file_id = $1 AND drafts.status = 'active'
+      AND drafts.deleted_at IS NULL AND cards.deleted_at IS NULL
+    RETURNING cards.id AS card_id, drafts.id AS draft_id
+)
+DELETE FROM cards USING applied
+WHERE cards.id = applied.draft_id
+RETURNING applied.card_id
+`
+
+func (q *Queries) ApplyMergeDrafts(ctx context.Context, fileID uuid.NullUUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, applyMergeDrafts, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var card_id uuid.UUID
+		if err := rows.Scan(&card_id); err != nil {
+			return nil, err
+		}
+		items = append(items, card_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createCard = `-- name: CreateCard :one
 INSERT INTO cards(
-    front, back, created_at, collection_id
+    front, back, created_at, collection_id, content_format
 ) VALUES 
-    ($1, $2, NOW(), $3)
+    ($1, $2, NOW(), $3, $4)
 RETURNING id
 `
 
 type CreateCardParams struct {
-	Front        string
-	Back         string
-	CollectionID uuid.UUID
+	Front         string
+	Back          string
+	CollectionID  uuid.UUID
+	ContentFormat string
 }
 
 func (q *Queries) CreateCard(ctx context.Context, arg CreateCardParams) (uuid.UUID, error) {
-	row := q.db.QueryRowContext(ctx, createCard, arg.Front, arg.Back, arg.CollectionID)
+	row := q.db.QueryRowContext(ctx, createCard,
+		arg.Front,
+		arg.Back,
+		arg.CollectionID,
+		arg.ContentFormat,
+	)
 	var id uuid.UUID
 	err := row.Scan(&id)
 	return id, err
 }
 
+const createMergeDrafts = `-- name: CreateMergeDrafts :exec
+INSERT INTO card_merge_drafts(card_id) SELECT unnest($1::uuid[])
+`
+
+func (q *Queries) CreateMergeDrafts(ctx context.Context, cardIds []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, createMergeDrafts, pq.Array(cardIds))
+	return err
+}
+
 const deleteAllCards = `-- name: DeleteAllCards :exec
-DELETE FROM cards WHERE collection_id=$1
+UPDATE cards SET deleted_at=NOW() WHERE collection_id=$1 AND deleted_at IS NULL
 `
 
 func (q *Queries) DeleteAllCards(ctx context.Context, collectionID uuid.UUID) error {
@@ -42,7 +96,7 @@ func (q *Queries) DeleteAllCards(ctx context.Context, collectionID uuid.UUID) er
 }
 
 const deleteCard = `-- name: DeleteCard :exec
-DELETE FROM cards WHERE id=$1 and collection_id=$2
+UPDATE cards SET deleted_at=NOW() WHERE id=$1 and collection_id=$2 AND deleted_at IS NULL
 `
 
 type DeleteCardParams struct {
@@ -55,11 +109,26 @@ func (q *Queries) DeleteCard(ctx context.Context, arg DeleteCardParams) error {
 	return err
 }
 
+const deleteFileCards = `-- name: DeleteFileCards :exec
+UPDATE cards SET deleted_at=NOW() WHERE file_id=$1 AND collection_id=$2 AND deleted_at IS NULL
+`
+
+type DeleteFileCardsParams struct {
+	FileID       uuid.NullUUID
+	CollectionID uuid.UUID
+}
+
+func (q *Queries) DeleteFileCards(ctx context.Context, arg DeleteFileCardsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteFileCards, arg.FileID, arg.CollectionID)
+	return err
+}
+
 const getCard = `-- name: GetCard :one
-SELECT cards.id, cards.front, cards.back, cards.created_at, cards.collection_id
+SELECT cards.id, cards.front, cards.back, cards.created_at, cards.due_date, cards.collection_id, cards.deleted_at, cards.tags, cards.duplicate_of, cards.status, cards.file_id, cards.source_page_start, cards.source_page_end, cards.source_excerpt, cards.content_format, cards.note_key, cards.note_ordinal, cards.note_anchor
 FROM cards
 JOIN collections ON cards.collection_id = collections.id
 WHERE cards.id = $1 AND collections.user_id = $2
+  AND cards.deleted_at IS NULL AND collections.deleted_at IS NULL
 `
 
 type GetCardParams struct {
@@ -75,13 +144,26 @@ func (q *Queries) GetCard(ctx context.Context, arg GetCardParams) (Card, error)
 		&i.Front,
 		&i.Back,
 		&i.CreatedAt,
+		&i.DueDate,
 		&i.CollectionID,
+		&i.DeletedAt,
+		pq.Array(&i.Tags),
+		&i.DuplicateOf,
+		&i.Status,
+		&i.FileID,
+		&i.SourcePageStart,
+		&i.SourcePageEnd,
+		&i.SourceExcerpt,
+		&i.ContentFormat,
+		&i.NoteKey,
+		&i.NoteOrdinal,
+		&i.NoteAnchor,
 	)
 	return i, err
 }
 
 const getCardsFomCollection = `-- name: GetCardsFomCollection :many
-SELECT id, front, back, created_at, collection_id FROM cards WHERE collection_id=$1
+SELECT id, front, back, created_at, due_date, collection_id, deleted_at, tags, duplicate_of, status, file_id, source_page_start, source_page_end, source_excerpt, content_format, note_key, note_ordinal, note_anchor FROM cards WHERE collection_id=$1 AND status='active' AND deleted_at IS NULL
 `
 
 func (q *Queries) GetCardsFomCollection(ctx context.Context, collectionID uuid.UUID) ([]Card, error) {
@@ -98,7 +180,20 @@ func (q *Queries) GetCardsFomCollection(ctx context.Context, collectionID uuid.U
 			&i.Front,
 			&i.Back,
 			&i.CreatedAt,
+			&i.DueDate,
 			&i.CollectionID,
+			&i.DeletedAt,
+			pq.Array(&i.Tags),
+			&i.DuplicateOf,
+			&i.Status,
+			&i.FileID,
+			&i.SourcePageStart,
+			&i.SourcePageEnd,
+			&i.SourceExcerpt,
+			&i.ContentFormat,
+			&i.NoteKey,
+			&i.NoteOrdinal,
+			&i.NoteAnchor,
 		); err != nil {
 			return nil, err
 		}
@@ -114,7 +209,7 @@ func (q *Queries) GetCardsFomCollection(ctx context.Context, collectionID uuid.U
 }
 
 const getTotalCardCount = `-- name: GetTotalCardCount :one
-SELECT COUNT(*) FROM cards WHERE collection_id= $1
+SELECT COUNT(*) FROM cards WHERE collection_id= $1 AND status='active' AND deleted_at IS NULL
 `
 
 func (q *Queries) GetTotalCardCount(ctx context.Context, collectionID uuid.UUID) (int64, error) {
@@ -124,17 +219,484 @@ func (q *Queries) GetTotalCardCount(ctx context.Context, collectionID uuid.UUID)
 	return count, err
 }
 
-const updateCard = `-- name: UpdateCard :exec
-UPDATE cards SET front=$1, back=$2 WHERE id=$3
+const importCard = `-- name: ImportCard :one
+INSERT INTO cards(
+    front, back, created_at, due_date, collection_id, file_id, tags, status, content_format
+) VALUES
+    ($1, $2, NOW(), COALESCE($3::timestamp, NOW()), $4, $5, $6, 'active', 'markdown')
+RETURNING id
 `
 
-type UpdateCardParams struct {
+type ImportCardParams struct {
+	Front        string
+	Back         string
+	DueDate      sql.NullTime
+	CollectionID uuid.UUID
+	FileID       uuid.NullUUID
+	Tags         []string
+}
+
+func (q *Queries) ImportCard(ctx context.Context, arg ImportCardParams) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, importCard,
+		arg.Front,
+		arg.Back,
+		arg.DueDate,
+		arg.CollectionID,
+		arg.FileID,
+		pq.Array(arg.Tags),
+	)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const insertCards = `-- name: InsertCards :exec
+INSERT INTO cards(
+    id, front, back, created_at, due_date, collection_id, file_id, tags, status,
+    duplicate_of, source_page_start, source_page_end, source_excerpt, content_format,
+    note_key, note_ordinal, note_anchor
+)
+SELECT u.id, u.front, u.back, NOW(), COALESCE(u.due_date, NOW()), $1, u.file_id,
+    ARRAY(SELECT jsonb_array_elements_text(u.tags)), $2,
+    u.duplicate_of, u.page_start, u.page_end, u.excerpt, $3,
+    u.note_key, u.note_ordinal, u.note_anchor
+FROM unnest(
+    $4::uuid[], $5::text[], $6::text[], $7::timestamp[], $8::uuid[],
+    $9::jsonb[], $10::uuid[], $11::int[], $12::int[], $13::text[],
+    $14::text[], $15::int[], $16::text[]
+) AS u(id, front, back, due_date, file_id, tags, duplicate_of, page_start, page_end, excerpt, note_key, note_ordinal, note_anchor)
+`
+
+type InsertCardsParams struct {
+	CollectionID  uuid.UUID
+	Status        string
+	ContentFormat string
+	Ids           []uuid.UUID
+	Fronts        []string
+	Backs         []string
+	DueDates      []sql.NullTime
+	FileIds       []uuid.NullUUID
+	Tags          []string
+	DuplicateOfs  []uuid.NullUUID
+	PageStarts    []sql.NullInt32
+	PageEnds      []sql.NullInt32
+	Excerpts      []sql.NullString
+	NoteKeys      []sql.NullString
+	NoteOrdinals  []sql.NullInt32
+	NoteAnchors   []sql.NullString
+}
+
+func (q *Queries) InsertCards(ctx context.Context, arg InsertCardsParams) error {
+	_, err := q.db.ExecContext(ctx, insertCards,
+		arg.CollectionID,
+		arg.Status,
+		arg.ContentFormat,
+		pq.Array(arg.Ids),
+		pq.Array(arg.Fronts),
+		pq.Array(arg.Backs),
+		pq.Array(arg.DueDates),
+		pq.Array(arg.FileIds),
+		pq.Array(arg.Tags),
+		pq.Array(arg.DuplicateOfs),
+		pq.Array(arg.PageStarts),
+		pq.Array(arg.PageEnds),
+		pq.Array(arg.Excerpts),
+		pq.Array(arg.NoteKeys),
+		pq.Array(arg.NoteOrdinals),
+		pq.Array(arg.NoteAnchors),
+	)
+	return err
+}
+
+const listCollectionCardTexts = `-- name: ListCollectionCardTexts :many
+SELECT id, front, back FROM cards WHERE collection_id=$1 AND status='active' AND deleted_at IS NULL
+`
+
+type ListCollectionCardTextsRow struct {
+	ID    uuid.UUID
 	Front string
 	Back  string
-	ID    uuid.UUID
 }
 
-func (q *Queries) UpdateCard(ctx context.Context, arg UpdateCardParams) error {
-	_, err := q.db.ExecContext(ctx, updateCard, arg.Front, arg.Back, arg.ID)
+func (q *Queries) ListCollectionCardTexts(ctx context.Context, collectionID uuid.UUID) ([]ListCollectionCardTextsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCollectionCardTexts, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCollectionCardTextsRow
+	for rows.Next() {
+		var i ListCollectionCardTextsRow
+		if err := rows.Scan(&i.ID, &i.Front, &i.Back); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedCards = `-- name: ListDeletedCards :many
+SELECT cards.id, cards.front, cards.back, cards.created_at, cards.due_date, cards.collection_id, cards.deleted_at, cards.tags, cards.duplicate_of, cards.status, cards.file_id, cards.source_page_start, cards.source_page_end, cards.source_excerpt, cards.content_format, cards.note_key, cards.note_ordinal, cards.note_anchor
+FROM cards
+JOIN collections ON cards.collection_id = collections.id
+WHERE collections.user_id = $1
+  AND cards.deleted_at IS NOT NULL AND collections.deleted_at IS NULL
+ORDER BY cards.deleted_at DESC
+`
+
+func (q *Queries) ListDeletedCards(ctx context.Context, userID uuid.UUID) ([]Card, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedCards, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Card
+	for rows.Next() {
+		var i Card
+		if err := rows.Scan(
+			&i.ID,
+			&i.Front,
+			&i.Back,
+			&i.CreatedAt,
+			&i.DueDate,
+			&i.CollectionID,
+			&i.DeletedAt,
+			pq.Array(&i.Tags),
+			&i.DuplicateOf,
+			&i.Status,
+			&i.FileID,
+			&i.SourcePageStart,
+			&i.SourcePageEnd,
+			&i.SourceExcerpt,
+			&i.ContentFormat,
+			&i.NoteKey,
+			&i.NoteOrdinal,
+			&i.NoteAnchor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDraftCards = `-- name: ListDraftCards :many
+SELECT cards.id, cards.front, cards.back, cards.created_at, cards.due_date, cards.collection_id, cards.deleted_at, cards.tags, cards.duplicate_of, cards.status, cards.file_id, cards.source_page_start, cards.source_page_end, cards.source_excerpt, cards.content_format, cards.note_key, cards.note_ordinal, cards.note_anchor
+FROM cards
+JOIN collections ON cards.collection_id = collections.id
+WHERE cards.file_id = $1 AND collections.user_id = $2 AND cards.status = 'draft'
+  AND cards.deleted_at IS NULL AND collections.deleted_at IS NULL
+ORDER BY cards.created_at
+`
+
+type ListDraftCardsParams struct {
+	FileID uuid.NullUUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) ListDraftCards(ctx context.Context, arg ListDraftCardsParams) ([]Card, error) {
+	rows, err := q.db.QueryContext(ctx, listDraftCards, arg.FileID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Card
+	for rows.Next() {
+		var i Card
+		if err := rows.Scan(
+			&i.ID,
+			&i.Front,
+			&i.Back,
+			&i.CreatedAt,
+			&i.DueDate,
+			&i.CollectionID,
+			&i.DeletedAt,
+			pq.Array(&i.Tags),
+			&i.DuplicateOf,
+			&i.Status,
+			&i.FileID,
+			&i.SourcePageStart,
+			&i.SourcePageEnd,
+			&i.SourceExcerpt,
+			&i.ContentFormat,
+			&i.NoteKey,
+			&i.NoteOrdinal,
+			&i.NoteAnchor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMergeDrafts = `-- name: ListMergeDrafts :many
+SELECT card_merge_drafts.card_id
+FROM card_merge_drafts
+JOIN cards ON card_merge_drafts.card_id = cards.id
+WHERE cards.file_id = $1 AND cards.status = 'draft' AND cards.deleted_at IS NULL
+`
+
+func (q *Queries) ListMergeDrafts(ctx context.Context, fileID uuid.NullUUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listMergeDrafts, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var card_id uuid.UUID
+		if err := rows.Scan(&card_id); err != nil {
+			return nil, err
+		}
+		items = append(items, card_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecentCardFronts = `-- name: ListRecentCardFronts :many
+SELECT front FROM cards
+WHERE collection_id=$1 AND status='active' AND deleted_at IS NULL
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type ListRecentCardFrontsParams struct {
+	CollectionID uuid.UUID
+	Limit        int32
+}
+
+func (q *Queries) ListRecentCardFronts(ctx context.Context, arg ListRecentCardFrontsParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listRecentCardFronts, arg.CollectionID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var front string
+		if err := rows.Scan(&front); err != nil {
+			return nil, err
+		}
+		items = append(items, front)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeCards = `-- name: PurgeCards :exec
+DELETE FROM cards
+WHERE deleted_at < $1
+   OR collection_id IN (SELECT id FROM collections WHERE collections.deleted_at < $1)
+`
+
+func (q *Queries) PurgeCards(ctx context.Context, deletedAt sql.NullTime) error {
+	_, err := q.db.ExecContext(ctx, purgeCards, deletedAt)
+	return err
+}
+
+const restoreAllCards = `-- name: RestoreAllCards :exec
+UPDATE cards SET deleted_at=NULL
+FROM collections
+WHERE cards.collection_id = collections.id
+  AND collections.id = $1 AND cards.deleted_at = collections.deleted_at
+`
+
+func (q *Queries) RestoreAllCards(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, restoreAllCards, id)
 	return err
 }
+
+const restoreCard = `-- name: RestoreCard :execrows
+UPDATE cards SET deleted_at=NULL
+FROM collections
+WHERE cards.collection_id = collections.id
+  AND cards.id = $1 AND collections.user_id = $2
+  AND cards.deleted_at IS NOT NULL AND collections.deleted_at IS NULL
+`
+
+type RestoreCardParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RestoreCard(ctx context.Context, arg RestoreCardParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreCard, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restoreFileCards = `-- name: RestoreFileCards :exec
+UPDATE cards SET deleted_at=NULL
+FROM files
+WHERE cards.file_id = files.id
+  AND files.id = $1 AND cards.deleted_at = files.deleted_at
+`
+
+func (q *Queries) RestoreFileCards(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, restoreFileCards, id)
+	return err
+}
+
+const setAllDraftsStatus = `-- name: SetAllDraftsStatus :execrows
+UPDATE cards SET status=$1
+FROM collections
+WHERE cards.collection_id = collections.id
+  AND cards.file_id = $2 AND collections.user_id = $3
+  AND cards.status = 'draft' AND cards.deleted_at IS NULL
+`
+
+type SetAllDraftsStatusParams struct {
+	Status string
+	FileID uuid.NullUUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) SetAllDraftsStatus(ctx context.Context, arg SetAllDraftsStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setAllDraftsStatus, arg.Status, arg.FileID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setCardDuplicateOf = `-- name: SetCardDuplicateOf :exec
+UPDATE cards SET duplicate_of=$1 WHERE id=$2
+`
+
+type SetCardDuplicateOfParams struct {
+	DuplicateOf uuid.NullUUID
+	ID          uuid.UUID
+}
+
+func (q *Queries) SetCardDuplicateOf(ctx context.Context, arg SetCardDuplicateOfParams) error {
+	_, err := q.db.ExecContext(ctx, setCardDuplicateOf, arg.DuplicateOf, arg.ID)
+	return err
+}
+
+const setCardSource = `-- name: SetCardSource :exec
+UPDATE cards SET file_id=$2, source_page_start=$3, source_page_end=$4, source_excerpt=$5 WHERE id=$1
+`
+
+type SetCardSourceParams struct {
+	ID              uuid.UUID
+	FileID          uuid.NullUUID
+	SourcePageStart sql.NullInt32
+	SourcePageEnd   sql.NullInt32
+	SourceExcerpt   sql.NullString
+}
+
+func (q *Queries) SetCardSource(ctx context.Context, arg SetCardSourceParams) error {
+	_, err := q.db.ExecContext(ctx, setCardSource,
+		arg.ID,
+		arg.FileID,
+		arg.SourcePageStart,
+		arg.SourcePageEnd,
+		arg.SourceExcerpt,
+	)
+	return err
+}
+
+const setCardTags = `-- name: SetCardTags :exec
+UPDATE cards SET tags=$1 WHERE id=$2
+`
+
+type SetCardTagsParams struct {
+	Tags []string
+	ID   uuid.UUID
+}
+
+func (q *Queries) SetCardTags(ctx context.Context, arg SetCardTagsParams) error {
+	_, err := q.db.ExecContext(ctx, setCardTags, pq.Array(arg.Tags), arg.ID)
+	return err
+}
+
+const setDraftsStatus = `-- name: SetDraftsStatus :execrows
+UPDATE cards SET status=$1
+FROM collections
+WHERE cards.collection_id = collections.id
+  AND cards.file_id = $2 AND collections.user_id = $3
+  AND cards.id = ANY($4::uuid[])
+  AND cards.status = 'draft' AND cards.deleted_at IS NULL
+`
+
+type SetDraftsStatusParams struct {
+	Status  string
+	FileID  uuid.NullUUID
+	UserID  uuid.UUID
+	CardIds []uuid.UUID
+}
+
+func (q *Queries) SetDraftsStatus(ctx context.Context, arg SetDraftsStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setDraftsStatus,
+		arg.Status,
+		arg.FileID,
+		arg.UserID,
+		pq.Array(arg.CardIds),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateCard = `-- name: UpdateCard :execrows
+UPDATE cards SET front=$1, back=$2, tags=COALESCE($3::text[], tags),
+    content_format=COALESCE(NULLIF($4::text, ''), content_format)
+WHERE id=$5 AND collection_id=$6 AND deleted_at IS NULL
+`
+
+type UpdateCardParams struct {
+	Front         string
+	Back          string
+	Tags          []string
+	ContentFormat string
+	ID            uuid.UUID
+	CollectionID  uuid.UUID
+}
+
+func (q *Queries) UpdateCard(ctx context.Context, arg UpdateCardParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateCard,
+		arg.Front,
+		arg.Back,
+		pq.Array(arg.Tags),
+		arg.ContentFormat,
+		arg.ID,
+		arg.CollectionID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
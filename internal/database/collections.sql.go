@@ -7,12 +7,14 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const checkUserCollectionOwnership = `-- name: CheckUserCollectionOwnership :one
-SELECT 1 FROM collections WHERE id = $1 AND user_id = $2
+SELECT 1 FROM collections WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 `
 
 type CheckUserCollectionOwnershipParams struct {
@@ -49,7 +51,7 @@ func (q *Queries) CreateCollection(ctx context.Context, arg CreateCollectionPara
 }
 
 const deleteCollection = `-- name: DeleteCollection :exec
-DELETE FROM collections WHERE id=$1 and user_id=$2
+UPDATE collections SET deleted_at=NOW() WHERE id=$1 and user_id=$2 AND deleted_at IS NULL
 `
 
 type DeleteCollectionParams struct {
@@ -63,7 +65,7 @@ func (q *Queries) DeleteCollection(ctx context.Context, arg DeleteCollectionPara
 }
 
 const getCollectionById = `-- name: GetCollectionById :one
-SELECT id, created_at, updated_at, name, user_id FROM collections WHERE id=$1 and user_id=$2
+SELECT id, created_at, updated_at, name, user_id, deleted_at FROM collections WHERE id=$1 and user_id=$2 AND deleted_at IS NULL
 `
 
 type GetCollectionByIdParams struct {
@@ -80,12 +82,13 @@ func (q *Queries) GetCollectionById(ctx context.Context, arg GetCollectionByIdPa
 		&i.UpdatedAt,
 		&i.Name,
 		&i.UserID,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const listCollections = `-- name: ListCollections :many
-SELECT id, name FROM collections WHERE user_id=$1
+SELECT id, name FROM collections WHERE user_id=$1 AND deleted_at IS NULL
 `
 
 type ListCollectionsRow struct {
@@ -115,3 +118,82 @@ func (q *Queries) ListCollections(ctx context.Context, userID uuid.UUID) ([]List
 	}
 	return items, nil
 }
+
+const listDeletedCollections = `-- name: ListDeletedCollections :many
+SELECT id, created_at, updated_at, name, user_id, deleted_at FROM collections WHERE user_id=$1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC
+`
+
+func (q *Queries) ListDeletedCollections(ctx context.Context, userID uuid.UUID) ([]Collection, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedCollections, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Collection
+	for rows.Next() {
+		var i Collection
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.UserID,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockLiveCollection = `-- name: LockLiveCollection :one
+SELECT id FROM collections WHERE id=$1 AND deleted_at IS NULL FOR SHARE
+`
+
+func (q *Queries) LockLiveCollection(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	row := q.db.QueryRowContext(ctx, lockLiveCollection, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const purgeCollections = `-- name: PurgeCollections :exec
+DELETE FROM collections
+WHERE deleted_at < $1
+  AND NOT EXISTS (SELECT 1 FROM files WHERE files.collection_id = collections.id)
+  AND NOT (id = ANY($2::uuid[]))
+`
+
+type PurgeCollectionsParams struct {
+	DeletedAt sql.NullTime
+	Kept      []uuid.UUID
+}
+
+func (q *Queries) PurgeCollections(ctx context.Context, arg PurgeCollectionsParams) error {
+	_, err := q.db.ExecContext(ctx, purgeCollections, arg.DeletedAt, pq.Array(arg.Kept))
+	return err
+}
+
+const restoreCollection = `-- name: RestoreCollection :execrows
+UPDATE collections SET deleted_at=NULL WHERE id=$1 and user_id=$2 AND deleted_at IS NOT NULL
+`
+
+type RestoreCollectionParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RestoreCollection(ctx context.Context, arg RestoreCollectionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreCollection, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -57,7 +57,7 @@ func (q *Queries) CraeteFileEntry(ctx context.Context, arg CraeteFileEntryParams
 }
 
 const deleteAllFiles = `-- name: DeleteAllFiles :exec
-DELETE FROM files WHERE collection_id=$1 and user_id=$2
+UPDATE files SET deleted_at=NOW() WHERE collection_id=$1 and user_id=$2 AND deleted_at IS NULL
 `
 
 type DeleteAllFilesParams struct {
@@ -70,8 +70,25 @@ func (q *Queries) DeleteAllFiles(ctx context.Context, arg DeleteAllFilesParams)
 	return err
 }
 
+const deleteCollectionFile = `-- name: DeleteCollectionFile :execrows
+UPDATE files SET deleted_at=NOW() WHERE id=$1 AND collection_id=$2 AND deleted_at IS NULL
+`
+
+type DeleteCollectionFileParams struct {
+	ID           uuid.UUID
+	CollectionID uuid.UUID
+}
+
+func (q *Queries) DeleteCollectionFile(ctx context.Context, arg DeleteCollectionFileParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCollectionFile, arg.ID, arg.CollectionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteFile = `-- name: DeleteFile :exec
-DELETE FROM files WHERE id=$1
+UPDATE files SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL
 `
 
 func (q *Queries) DeleteFile(ctx context.Context, id uuid.UUID) error {
@@ -79,8 +96,46 @@ func (q *Queries) DeleteFile(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
+const getCollectionFileName = `-- name: GetCollectionFileName :one
+SELECT file_name FROM files WHERE id=$1 AND collection_id=$2
+`
+
+type GetCollectionFileNameParams struct {
+	ID           uuid.UUID
+	CollectionID uuid.UUID
+}
+
+func (q *Queries) GetCollectionFileName(ctx context.Context, arg GetCollectionFileNameParams) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getCollectionFileName, arg.ID, arg.CollectionID)
+	var file_name sql.NullString
+	err := row.Scan(&file_name)
+	return file_name, err
+}
+
+const getFileFormat = `-- name: GetFileFormat :one
+SELECT format FROM files WHERE id=$1
+`
+
+func (q *Queries) GetFileFormat(ctx context.Context, id uuid.UUID) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getFileFormat, id)
+	var format sql.NullString
+	err := row.Scan(&format)
+	return format, err
+}
+
+const getFileName = `-- name: GetFileName :one
+SELECT file_name FROM files WHERE id=$1
+`
+
+func (q *Queries) GetFileName(ctx context.Context, id uuid.UUID) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getFileName, id)
+	var file_name sql.NullString
+	err := row.Scan(&file_name)
+	return file_name, err
+}
+
 const getFilesForCollection = `-- name: GetFilesForCollection :many
-SELECT id, collection_id, user_id, file_name, format, uploaded_at, processed FROM files WHERE collection_id=$1 and user_id = $2
+SELECT id, collection_id, user_id, file_name, format, uploaded_at, processed, deleted_at FROM files WHERE collection_id=$1 and user_id = $2 AND deleted_at IS NULL
 `
 
 type GetFilesForCollectionParams struct {
@@ -105,6 +160,80 @@ func (q *Queries) GetFilesForCollection(ctx context.Context, arg GetFilesForColl
 			&i.Format,
 			&i.UploadedAt,
 			&i.Processed,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCollectionFileNames = `-- name: ListCollectionFileNames :many
+SELECT id, file_name FROM files WHERE collection_id=$1
+`
+
+type ListCollectionFileNamesRow struct {
+	ID       uuid.UUID
+	FileName sql.NullString
+}
+
+func (q *Queries) ListCollectionFileNames(ctx context.Context, collectionID uuid.UUID) ([]ListCollectionFileNamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCollectionFileNames, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCollectionFileNamesRow
+	for rows.Next() {
+		var i ListCollectionFileNamesRow
+		if err := rows.Scan(&i.ID, &i.FileName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedFiles = `-- name: ListDeletedFiles :many
+SELECT files.id, files.collection_id, files.user_id, files.file_name, files.format, files.uploaded_at, files.processed, files.deleted_at
+FROM files
+JOIN collections ON files.collection_id = collections.id
+WHERE files.user_id = $1 AND files.file_name IS NOT NULL
+  AND files.deleted_at IS NOT NULL AND collections.deleted_at IS NULL
+ORDER BY files.deleted_at DESC
+`
+
+func (q *Queries) ListDeletedFiles(ctx context.Context, userID uuid.UUID) ([]File, error) {
+	rows, err := q.db.QueryContext(ctx, listDeletedFiles, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []File
+	for rows.Next() {
+		var i File
+		if err := rows.Scan(
+			&i.ID,
+			&i.CollectionID,
+			&i.UserID,
+			&i.FileName,
+			&i.Format,
+			&i.UploadedAt,
+			&i.Processed,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -119,6 +248,75 @@ func (q *Queries) GetFilesForCollection(ctx context.Context, arg GetFilesForColl
 	return items, nil
 }
 
+const listFilesWithoutPassages = `-- name: ListFilesWithoutPassages :many
+SELECT id, format FROM files
+WHERE processed AND deleted_at IS NULL AND format NOT IN ('apkg', 'zip', 'topic') AND id > $1::uuid
+  AND NOT EXISTS (SELECT 1 FROM file_chunks WHERE file_chunks.file_id = files.id)
+  AND NOT EXISTS (SELECT 1 FROM file_passage_attempts WHERE file_passage_attempts.file_id = files.id)
+ORDER BY id
+LIMIT $2
+`
+
+type ListFilesWithoutPassagesParams struct {
+	After    uuid.UUID
+	MaxFiles int32
+}
+
+type ListFilesWithoutPassagesRow struct {
+	ID     uuid.UUID
+	Format sql.NullString
+}
+
+func (q *Queries) ListFilesWithoutPassages(ctx context.Context, arg ListFilesWithoutPassagesParams) ([]ListFilesWithoutPassagesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listFilesWithoutPassages, arg.After, arg.MaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListFilesWithoutPassagesRow
+	for rows.Next() {
+		var i ListFilesWithoutPassagesRow
+		if err := rows.Scan(&i.ID, &i.Format); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPurgeableFiles = `-- name: ListPurgeableFiles :many
+SELECT id FROM files WHERE deleted_at < $1
+`
+
+func (q *Queries) ListPurgeableFiles(ctx context.Context, deletedAt sql.NullTime) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listPurgeableFiles, deletedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const processed = `-- name: Processed :exec
 UPDATE files SET processed = $1 WHERE id = $2
 `
@@ -143,3 +341,45 @@ func (q *Queries) ProcessedCheck(ctx context.Context, id uuid.UUID) (bool, error
 	err := row.Scan(&processed)
 	return processed, err
 }
+
+const purgeFile = `-- name: PurgeFile :exec
+DELETE FROM files WHERE id=$1
+`
+
+func (q *Queries) PurgeFile(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, purgeFile, id)
+	return err
+}
+
+const restoreAllFiles = `-- name: RestoreAllFiles :exec
+UPDATE files SET deleted_at=NULL
+FROM collections
+WHERE files.collection_id = collections.id
+  AND collections.id = $1 AND files.deleted_at = collections.deleted_at
+`
+
+func (q *Queries) RestoreAllFiles(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, restoreAllFiles, id)
+	return err
+}
+
+const restoreFile = `-- name: RestoreFile :execrows
+UPDATE files SET deleted_at=NULL
+FROM collections
+WHERE files.collection_id = collections.id
+  AND files.id = $1 AND files.user_id = $2
+  AND files.deleted_at IS NOT NULL AND collections.deleted_at IS NULL
+`
+
+type RestoreFileParams struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+}
+
+func (q *Queries) RestoreFile(ctx context.Context, arg RestoreFileParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreFile, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -12,11 +12,83 @@ import (
 )
 
 type Card struct {
+	ID              uuid.UUID
+	Front           string
+	Back            string
+	CreatedAt       time.Time
+	DueDate         sql.NullTime
+	CollectionID    uuid.UUID
+	DeletedAt       sql.NullTime
+	Tags            []string
+	DuplicateOf     uuid.NullUUID
+	Status          string
+	FileID          uuid.NullUUID
+	SourcePageStart sql.NullInt32
+	SourcePageEnd   sql.NullInt32
+	SourceExcerpt   sql.NullString
+	ContentFormat   string
+	NoteKey         sql.NullString
+	NoteOrdinal     sql.NullInt32
+	NoteAnchor      sql.NullString
+}
+
+type CardAssist struct {
+	CardID    uuid.UUID
+	Kind      string
+	Revision  string
+	Body      string
+	CreatedAt time.Time
+}
+
+type CardEmbedding struct {
+	CardID    uuid.UUID
+	Model     string
+	Embedding []float32
+	CreatedAt time.Time
+}
+
+type CardMedium struct {
 	ID           uuid.UUID
-	Front        string
-	Back         string
-	CreatedAt    time.Time
 	CollectionID uuid.UUID
+	CardID       uuid.NullUUID
+	FileID       uuid.NullUUID
+	Page         sql.NullInt32
+	ContentType  string
+	Kind         string
+	Uploaded     bool
+	CreatedAt    time.Time
+}
+
+type CardMergeDraft struct {
+	CardID uuid.UUID
+}
+
+type CardMnemonic struct {
+	ID        uuid.UUID
+	CardID    uuid.UUID
+	Kind      string
+	Body      string
+	Status    string
+	CreatedAt time.Time
+}
+
+type CardReview struct {
+	ID           uuid.UUID
+	CardID       uuid.UUID
+	ReviewedAt   time.Time
+	Rating       int32
+	IntervalDays int32
+	EaseFactor   int32
+	DurationMs   int32
+}
+
+type CardSchedule struct {
+	CardID       uuid.UUID
+	State        string
+	IntervalDays int32
+	EaseFactor   int32
+	Reps         int32
+	Lapses       int32
 }
 
 type Collection struct {
@@ -25,6 +97,7 @@ type Collection struct {
 	UpdatedAt time.Time
 	Name      string
 	UserID    uuid.UUID
+	DeletedAt sql.NullTime
 }
 
 type File struct {
@@ -35,6 +108,47 @@ type File struct {
 	Format       sql.NullString
 	UploadedAt   time.Time
 	Processed    bool
+	DeletedAt    sql.NullTime
+}
+
+type FileChunk struct {
+	ID        uuid.UUID
+	FileID    uuid.UUID
+	Position  int32
+	Page      int32
+	Body      string
+	Model     string
+	Embedding []float32
+	CreatedAt time.Time
+}
+
+type FilePassageAttempt struct {
+	FileID      uuid.UUID
+	AttemptedAt time.Time
+}
+
+type FileText struct {
+	FileID    uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+type PdfExport struct {
+	ID           uuid.UUID
+	CollectionID uuid.UUID
+	UserID       uuid.UUID
+	Layout       string
+	Status       string
+	CreatedAt    time.Time
+	CompletedAt  sql.NullTime
+}
+
+type PromptTemplate struct {
+	ID           uuid.UUID
+	CollectionID uuid.UUID
+	Version      int32
+	Body         string
+	CreatedAt    time.Time
 }
 
 type User struct {
@@ -45,3 +159,11 @@ type User struct {
 	Email     string
 	Password  string
 }
+
+type VaultNote struct {
+	CollectionID uuid.UUID
+	NoteKey      string
+	ContentHash  string
+	FileID       uuid.NullUUID
+	UpdatedAt    time.Time
+}
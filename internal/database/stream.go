@@ -0,0 +1,44 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// EachCardForExport is ListCardsForExport calling fn on each row as it is read,
+// so a large export never holds the whole collection in memory.
+func (q *Queries) EachCardForExport(ctx context.Context, collectionID uuid.UUID, fn func(ListCardsForExportRow) error) error {
+	rows, err := q.db.QueryContext(ctx, listCardsForExport, collectionID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i ListCardsForExportRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Front,
+			&i.Back,
+			pq.Array(&i.Tags),
+			&i.ContentFormat,
+			&i.DueDate,
+			&i.CreatedAt,
+			&i.State,
+			&i.IntervalDays,
+			&i.EaseFactor,
+			&i.Reps,
+			&i.Lapses,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
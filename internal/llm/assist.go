@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// a nudge towards the answer that doesn't give it away
+	AssistHint = "hint"
+	// why the answer is what it is, from the material when there is some
+	AssistExplain = "explain"
+)
+
+// longest material sent along with an explanation, in characters
+const maxAssistMaterial = 12000
+
+// AssistRequest asks for help with a card during review.
+type AssistRequest struct {
+	Kind  string
+	Front string
+	Back  string
+	// the quote of the material the card was made from, if any
+	Source string
+	// text of the pages the card was made from, or the pasted text, if any
+	Material string
+}
+
+func ValidAssist(kind string) bool {
+	return kind == AssistHint || kind == AssistExplain
+}
+
+func assistPrompt(req AssistRequest) string {
+	var b strings.Builder
+	b.WriteString("You are a tutor helping a student review a cue card.\n\n")
+	fmt.Fprintf(&b, "The card:\nFront: %s\nBack: %s\n", req.Front, req.Back)
+	if req.Source != "" {
+		fmt.Fprintf(&b, "It was made from this quote of the study material: %q\n", req.Source)
+	}
+	material := req.Material
+	if runes := []rune(material); len(runes) > maxAssistMaterial {
+		material = string(runes[:maxAssistMaterial])
+	}
+	if req.Kind == AssistExplain && strings.TrimSpace(material) != "" {
+		fmt.Fprintf(&b, "\nThe part of the material it was made from:\n%s\n", material)
+	}
+
+	if req.Kind == AssistHint {
+		b.WriteString(`
+Write one short hint, at most two sentences, that helps the student recall the answer.
+Never state the answer or any of its key terms; point at a related idea, the first letter or what kind of answer is expected.`)
+	} else {
+		b.WriteString(`
+Explain the answer so the student understands it rather than memorizes it: why it is true, how it connects to related ideas,
+and a short example if it helps. Keep it under 200 words.`)
+		if material != "" || req.Source != "" {
+			b.WriteString(" Base the explanation on the material, and say so when something goes beyond it.")
+		}
+	}
+	b.WriteString(`
+Write in the language of the card, in Markdown: formulas in LaTeX between $...$, code in fenced blocks.
+Answer with the text only, no greeting or heading.
+`)
+	return b.String()
+}
+
+// assistResult cleans up a reply. A hint that gives a short answer away has it blanked out.
+func assistResult(req AssistRequest, text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("empty response from the model")
+	}
+	answer := strings.TrimSpace(req.Back)
+	if req.Kind == AssistHint && answer != "" && len(answer) <= 60 && strings.Contains(strings.ToLower(text), strings.ToLower(answer)) {
+		text = replaceFold(text, answer, "____")
+	}
+	return text, nil
+}
+
+// replaceFold replaces old in s ignoring case.
+func replaceFold(s, old, new string) string {
+	lower, oldLower := strings.ToLower(s), strings.ToLower(old)
+	//lowering can change byte lengths, leave such text alone rather than cut a rune
+	if len(lower) != len(s) || len(oldLower) != len(old) {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, oldLower)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		b.WriteString(s[:i] + new)
+		s, lower = s[i+len(old):], lower[i+len(old):]
+	}
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	ChatUser      = "user"
+	ChatAssistant = "assistant"
+)
+
+// ChatMessage is an earlier turn of a conversation.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Passage is a piece of an uploaded file an answer may cite as [Number].
+type Passage struct {
+	Number int
+	// where it comes from, like "notes.pdf, page 3"
+	Source string
+	Text   string
+}
+
+type ChatRequest struct {
+	Question string
+	History  []ChatMessage
+	Passages []Passage
+}
+
+// runs of [n] markers after a space or punctuation, so code like a[0] isn't one
+var citationPattern = regexp.MustCompile(`(^|[^\w\]])((?:\[\d{1,2}\])+)`)
+
+var citationNumber = regexp.MustCompile(`\d+`)
+
+func chatPrompt(req ChatRequest) string {
+	var b strings.Builder
+	b.WriteString(`You answer a student's questions about their study material, using only the numbered passages
+from their uploaded files below. After each sentence that uses a passage, cite it like [2], several like [1][3].
+If the passages don't answer the question, say the files don't cover it rather than answering from general knowledge.
+Answer in the language of the question, in Markdown: formulas in LaTeX between $...$, code in fenced blocks.
+
+Passages:
+`)
+	for _, p := range req.Passages {
+		fmt.Fprintf(&b, "[%d] (%s)\n%s\n\n", p.Number, p.Source, p.Text)
+	}
+	if len(req.History) > 0 {
+		b.WriteString("Conversation so far:\n")
+		for _, m := range req.History {
+			who := "Student"
+			if m.Role == ChatAssistant {
+				who = "You"
+			}
+			fmt.Fprintf(&b, "%s: %s\n", who, m.Content)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Question: %s\n", req.Question)
+	return b.String()
+}
+
+func chatResult(text string) (string, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("empty response from the model")
+	}
+	return text, nil
+}
+
+// Citations returns the passage numbers an answer cites, in order of first use.
+func Citations(answer string) []int {
+	var numbers []int
+	seen := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		for _, number := range citationNumber.FindAllString(m[2], -1) {
+			n, err := strconv.Atoi(number)
+			if err != nil || seen[n] {
+				continue
+			}
+			seen[n] = true
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+// StripCitations removes the [n] markers of an answer, for text shown without its passages.
+func StripCitations(answer string) string {
+	answer = citationPattern.ReplaceAllString(answer, "$1")
+	//markers sat before punctuation, don't leave a space there
+	for _, p := range []string{" .", " ,", " ;", " :"} {
+		answer = strings.ReplaceAll(answer, p, p[1:])
+	}
+	return strings.TrimSpace(answer)
+}
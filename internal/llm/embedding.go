@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/google/generative-ai-go/genai"
+)
+
+const embeddingModel = "text-embedding-004"
+
+// the API rejects batches with more than 100 requests
+const maxEmbeddingBatch = 100
+
+// Embedder turns texts into vectors for semantic search.
+// Embed must return exactly one vector per text, in the same order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	EmbeddingModel() string
+}
+
+func (s *Gemini) EmbeddingModel() string {
+	return embeddingModel
+}
+
+func (s *Gemini) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbeddingBatch {
+		end := min(start+maxEmbeddingBatch, len(texts))
+
+		batch := s.embedder.NewBatch()
+		for _, text := range texts[start:end] {
+			batch.AddContent(genai.Text(text))
+		}
+		resp, err := s.embedder.BatchEmbedContents(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("error on embedding texts: %v", err)
+		}
+		if len(resp.Embeddings) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(resp.Embeddings))
+		}
+		for _, e := range resp.Embeddings {
+			vectors = append(vectors, e.Values)
+		}
+	}
+	return vectors, nil
+}
+
+// FakeEmbedder hashes words into a fixed number of buckets, so equal texts get equal vectors
+// and texts sharing words are similar. It needs no API key and is meant for tests and local runs.
+type FakeEmbedder struct {
+	Dimensions int
+}
+
+func (f FakeEmbedder) EmbeddingModel() string {
+	return fmt.Sprintf("fake-%d", f.dimensions())
+}
+
+func (f FakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, f.dimensions())
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, word := range words {
+			h := fnv.New32a()
+			h.Write([]byte(word))
+			vector[h.Sum32()%uint32(len(vector))]++
+		}
+		normalize(vector)
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+func (f FakeEmbedder) dimensions() int {
+	if f.Dimensions <= 0 {
+		return 64
+	}
+	return f.Dimensions
+}
+
+func normalize(vector []float32) {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vector {
+		vector[i] /= norm
+	}
+}
+
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
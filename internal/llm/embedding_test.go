@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestFakeEmbedderSimilarity(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		atLeast float64
+		below   float64
+	}{
+		{name: "same text", a: "What is photosynthesis?", b: "What is photosynthesis?", atLeast: 0.999, below: 1.001},
+		{name: "case and punctuation", a: "What is photosynthesis?", b: "what IS photosynthesis", atLeast: 0.999, below: 1.001},
+		{name: "shared words", a: "the capital of France is Paris", b: "Paris is the capital city of France", atLeast: 0.7, below: 1},
+		{name: "unrelated", a: "mitochondria produce ATP", b: "the treaty of Westphalia", atLeast: -1, below: 0.5},
+		{name: "empty", a: "", b: "anything", atLeast: 0, below: 0.001},
+	}
+
+	embedder := FakeEmbedder{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vectors, err := embedder.Embed(context.Background(), []string{tt.a, tt.b})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vectors) != 2 || len(vectors[0]) != 64 {
+				t.Fatalf("got %d vectors of %d dimensions, want 2 of 64", len(vectors), len(vectors[0]))
+			}
+			got := CosineSimilarity(vectors[0], vectors[1])
+			if got < tt.atLeast || got >= tt.below {
+				t.Errorf("similarity %.3f, want in [%.3f, %.3f)", got, tt.atLeast, tt.below)
+			}
+		})
+	}
+}
+
+func TestFakeEmbedderRanking(t *testing.T) {
+	embedder := FakeEmbedder{Dimensions: 256}
+	vectors, err := embedder.Embed(context.Background(), []string{
+		"krebs cycle in the mitochondria",
+		"the krebs cycle happens in the mitochondria matrix",
+		"glycolysis happens in the cytoplasm",
+		"french revolution 1789",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	near := CosineSimilarity(vectors[0], vectors[1])
+	related := CosineSimilarity(vectors[0], vectors[2])
+	far := CosineSimilarity(vectors[0], vectors[3])
+	if !(near > related && related >= far) {
+		t.Errorf("want near > related >= far, got %.3f, %.3f, %.3f", near, related, far)
+	}
+	if model := embedder.EmbeddingModel(); model != "fake-256" {
+		t.Errorf("model %q, want fake-256", model)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float32
+		want float64
+	}{
+		{name: "parallel", a: []float32{1, 2}, b: []float32{2, 4}, want: 1},
+		{name: "orthogonal", a: []float32{1, 0}, b: []float32{0, 3}, want: 0},
+		{name: "opposite", a: []float32{1, 1}, b: []float32{-1, -1}, want: -1},
+		{name: "zero vector", a: []float32{0, 0}, b: []float32{1, 1}, want: 0},
+		{name: "length mismatch", a: []float32{1}, b: []float32{1, 1}, want: 0},
+		{name: "empty", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FakeGenerator makes cards without a model: one per paragraph of a text and a
+// fixed number for a file, named after its hash. Only the count option is
+// followed. The same input always gives the same cards, it is meant for tests
+// and local runs.
+type FakeGenerator struct {
+	// cards made from a file, 3 when unset
+	FileCards int
+}
+
+func (f FakeGenerator) GenerateCardsFromFile(ctx context.Context, file io.Reader, figures []Figure, opts Options) (*FlashCardResponse, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:4])
+
+	n := f.FileCards
+	if opts.Count > 0 {
+		n = opts.Count
+	}
+	if n <= 0 {
+		n = 3
+	}
+	cards := make([]Card, n)
+	for i := range cards {
+		cards[i] = Card{
+			Front:     fmt.Sprintf("Question %d about %s", i+1, name),
+			Back:      fmt.Sprintf("Answer %d about %s", i+1, name),
+			PageStart: 1,
+			PageEnd:   1,
+		}
+		//the first cards show the figures, as a model would for diagrams
+		if i < len(figures) {
+			cards[i].Figure = figures[i].Number
+			cards[i].PageStart, cards[i].PageEnd = figures[i].Page, figures[i].Page
+		}
+	}
+	return &FlashCardResponse{Cards: cards}, nil
+}
+
+func (f FakeGenerator) GenerateCardsFromText(ctx context.Context, text string, figures []Figure, opts Options) (*FlashCardResponse, error) {
+	var cards []Card
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		first, _, _ := strings.Cut(paragraph, "\n")
+		cards = append(cards, Card{
+			Front:  fmt.Sprintf("What does the material say about %q?", truncate(first, 60)),
+			Back:   paragraph,
+			Source: truncate(first, 200),
+		})
+	}
+	if opts.Count > 0 && len(cards) > opts.Count {
+		cards = cards[:opts.Count]
+	}
+	return &FlashCardResponse{Cards: cards}, nil
+}
+
+// GenerateCardsFromTopic writes numbered cards about the topic, as many as for a file.
+func (f FakeGenerator) GenerateCardsFromTopic(ctx context.Context, topic string, opts Options) (*FlashCardResponse, error) {
+	n := f.FileCards
+	if opts.Count > 0 {
+		n = opts.Count
+	}
+	if n <= 0 {
+		n = 3
+	}
+	cards := make([]Card, n)
+	for i := range cards {
+		cards[i] = Card{
+			Front: fmt.Sprintf("What is fact %d about %s?", i+1, truncate(topic, 60)),
+			Back:  fmt.Sprintf("Fact %d about %s.", i+1, truncate(topic, 60)),
+		}
+	}
+	return &FlashCardResponse{Cards: cards}, nil
+}
+
+// RewriteCard changes the card in a fixed way per action: simplify keeps the
+// first sentence of the back, split makes a card of each sentence.
+func (f FakeGenerator) RewriteCard(ctx context.Context, req RewriteRequest) (*FlashCardResponse, error) {
+	card := Card{Front: req.Front, Back: req.Back}
+	sentences := splitSentences(req.Back)
+	switch req.Action {
+	case RewriteSimplify:
+		if len(sentences) > 0 {
+			card.Back = sentences[0]
+		}
+	case RewriteSplit:
+		if len(sentences) < 2 {
+			break
+		}
+		cards := make([]Card, len(sentences))
+		for i, sentence := range sentences {
+			cards[i] = Card{Front: fmt.Sprintf("%s (%d/%d)", req.Front, i+1, len(sentences)), Back: sentence}
+		}
+		return &FlashCardResponse{Cards: cards}, nil
+	case RewriteExample:
+		card.Back += "\n\nExample: " + truncate(req.Front, 60)
+	case RewriteRegenerate:
+		card.Front = "Explain: " + req.Front
+	}
+	return &FlashCardResponse{Cards: []Card{card}}, nil
+}
+
+// Assist hints with the first letter of the answer and explains with the answer and its source.
+func (f FakeGenerator) Assist(ctx context.Context, req AssistRequest) (string, error) {
+	if req.Kind == AssistHint {
+		first := []rune(strings.TrimSpace(req.Back))
+		if len(first) == 0 {
+			return "", fmt.Errorf("empty response from the model")
+		}
+		return fmt.Sprintf("The answer starts with %q.", first[0]), nil
+	}
+	text := req.Back
+	if req.Source != "" {
+		text += "\n\nThe material says: " + req.Source
+	}
+	return text, nil
+}
+
+// Mnemonics spells the first letters of a back with several words, and pictures the others.
+func (f FakeGenerator) Mnemonics(ctx context.Context, cards []MnemonicCard) ([]Mnemonic, error) {
+	mnemonics := make([]Mnemonic, len(cards))
+	for i, card := range cards {
+		words := strings.Fields(card.Back)
+		if len(words) < 2 {
+			mnemonics[i] = Mnemonic{Card: i + 1, Kind: MnemonicAssociation, Text: fmt.Sprintf("Picture %s written on %s.", card.Back, truncate(card.Front, 40))}
+			continue
+		}
+		var acronym strings.Builder
+		for _, word := range words {
+			acronym.WriteString(strings.ToUpper(string([]rune(word)[:1])))
+		}
+		mnemonics[i] = Mnemonic{Card: i + 1, Kind: MnemonicAcronym, Text: acronym.String()}
+	}
+	return mnemonics, nil
+}
+
+// Chat answers with the first sentence of the first passage, citing it.
+func (f FakeGenerator) Chat(ctx context.Context, req ChatRequest) (string, error) {
+	if len(req.Passages) == 0 {
+		return "The uploaded files don't cover this.", nil
+	}
+	first := req.Passages[0]
+	sentences := splitSentences(first.Text)
+	if len(sentences) == 0 {
+		return "The uploaded files don't cover this.", nil
+	}
+	return fmt.Sprintf("%s [%d]", sentences[0], first.Number), nil
+}
+
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i, r := range text {
+		if r == '.' || r == '!' || r == '?' || r == '\n' {
+			if s := strings.TrimSpace(text[start : i+1]); s != "" && s != "." {
+				sentences = append(sentences, s)
+			}
+			start = i + 1
+		}
+	}
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
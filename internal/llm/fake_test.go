@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFakeGenerateCardsFromFile(t *testing.T) {
+	figures := []Figure{{Number: 1, Page: 4}, {Number: 2, Page: 7}}
+	tests := []struct {
+		name    string
+		gen     FakeGenerator
+		figures []Figure
+		opts    Options
+		want    int
+	}{
+		{name: "default", want: 3},
+		{name: "configured", gen: FakeGenerator{FileCards: 5}, want: 5},
+		{name: "count wins", gen: FakeGenerator{FileCards: 5}, opts: Options{Count: 2}, want: 2},
+		{name: "figures", figures: figures, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.gen.GenerateCardsFromFile(context.Background(), strings.NewReader("pdf"), tt.figures, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Cards) != tt.want {
+				t.Fatalf("got %d cards, want %d", len(resp.Cards), tt.want)
+			}
+			for i, card := range resp.Cards {
+				wantFigure, wantPage := 0, 1
+				if i < len(tt.figures) {
+					wantFigure, wantPage = tt.figures[i].Number, tt.figures[i].Page
+				}
+				if card.Figure != wantFigure || card.PageStart != wantPage {
+					t.Errorf("card %d shows figure %d on page %d, want %d on %d", i, card.Figure, card.PageStart, wantFigure, wantPage)
+				}
+			}
+
+			again, _ := tt.gen.GenerateCardsFromFile(context.Background(), strings.NewReader("pdf"), tt.figures, tt.opts)
+			if !reflect.DeepEqual(resp, again) {
+				t.Error("same file gave different cards")
+			}
+		})
+	}
+}
+
+func TestFakeGenerateCardsFromText(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		opts   Options
+		fronts int
+	}{
+		{name: "paragraphs", text: "First one.\r\n\r\nSecond\nwith two lines.\n\n\n", fronts: 2},
+		{name: "count", text: "a\n\nb\n\nc", opts: Options{Count: 1}, fronts: 1},
+		{name: "empty", text: "  \n\n ", fronts: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := FakeGenerator{}.GenerateCardsFromText(context.Background(), tt.text, nil, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Cards) != tt.fronts {
+				t.Fatalf("got %d cards, want %d", len(resp.Cards), tt.fronts)
+			}
+		})
+	}
+}
+
+func TestFakeRewriteCard(t *testing.T) {
+	req := RewriteRequest{Front: "Cells", Back: "Cells are small. They divide."}
+	tests := []struct {
+		action string
+		want   []Card
+	}{
+		{action: RewriteSimplify, want: []Card{{Front: "Cells", Back: "Cells are small."}}},
+		{action: RewriteSplit, want: []Card{{Front: "Cells (1/2)", Back: "Cells are small."}, {Front: "Cells (2/2)", Back: "They divide."}}},
+		{action: RewriteExample, want: []Card{{Front: "Cells", Back: "Cells are small. They divide.\n\nExample: Cells"}}},
+		{action: RewriteRegenerate, want: []Card{{Front: "Explain: Cells", Back: "Cells are small. They divide."}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			req.Action = tt.action
+			resp, err := FakeGenerator{}.RewriteCard(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(resp.Cards, tt.want) {
+				t.Errorf("got %+v, want %+v", resp.Cards, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeAssist(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     AssistRequest
+		want    string
+		wantErr bool
+	}{
+		{name: "hint", req: AssistRequest{Kind: AssistHint, Back: " Paris"}, want: `The answer starts with 'P'.`},
+		{name: "empty hint", req: AssistRequest{Kind: AssistHint, Back: " "}, wantErr: true},
+		{name: "explanation", req: AssistRequest{Kind: AssistExplain, Back: "Paris", Source: "capital"}, want: "Paris\n\nThe material says: capital"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FakeGenerator{}.Assist(context.Background(), tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeMnemonics(t *testing.T) {
+	got, err := FakeGenerator{}.Mnemonics(context.Background(), []MnemonicCard{
+		{Front: "Planets", Back: "my very eager mother"},
+		{Front: "Capital of France", Back: "Paris"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Mnemonic{
+		{Card: 1, Kind: MnemonicAcronym, Text: "MVEM"},
+		{Card: 2, Kind: MnemonicAssociation, Text: "Picture Paris written on Capital of France."},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFakeChat(t *testing.T) {
+	tests := []struct {
+		name     string
+		passages []Passage
+		want     string
+	}{
+		{name: "no passages", want: "The uploaded files don't cover this."},
+		{name: "cites first", passages: []Passage{{Number: 2, Text: "ATP stores energy. It is made in mitochondria."}}, want: "ATP stores energy. [2]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FakeGenerator{}.Chat(context.Background(), ChatRequest{Question: "?", Passages: tt.passages})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeGenerateCardsFromTopic(t *testing.T) {
+	resp, err := FakeGenerator{}.GenerateCardsFromTopic(context.Background(), "photosynthesis", Options{Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Card{
+		{Front: "What is fact 1 about photosynthesis?", Back: "Fact 1 about photosynthesis."},
+		{Front: "What is fact 2 about photosynthesis?", Back: "Fact 2 about photosynthesis."},
+	}
+	if !reflect.DeepEqual(resp.Cards, want) {
+		t.Errorf("got %+v, want %+v", resp.Cards, want)
+	}
+}
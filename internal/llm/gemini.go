@@ -0,0 +1,220 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+)
+
+const defaultGeminiModel = "gemini-1.5-flash"
+
+// geminiCardSchema is cardSchema in the form the Gemini API takes.
+var geminiCardSchema = &genai.Schema{
+	Type:     genai.TypeObject,
+	Required: []string{"cards"},
+	Properties: map[string]*genai.Schema{
+		"cards": {
+			Type: genai.TypeArray,
+			Items: &genai.Schema{
+				Type:     genai.TypeObject,
+				Required: []string{"front", "back", "page_start", "page_end", "source", "figure"},
+				Properties: map[string]*genai.Schema{
+					"front":      {Type: genai.TypeString},
+					"back":       {Type: genai.TypeString},
+					"page_start": {Type: genai.TypeInteger},
+					"page_end":   {Type: genai.TypeInteger},
+					"source":     {Type: genai.TypeString},
+					"figure":     {Type: genai.TypeInteger},
+				},
+			},
+		},
+	},
+}
+
+// geminiMnemonicSchema is mnemonicSchema in the form the Gemini API takes.
+var geminiMnemonicSchema = &genai.Schema{
+	Type:     genai.TypeObject,
+	Required: []string{"mnemonics"},
+	Properties: map[string]*genai.Schema{
+		"mnemonics": {
+			Type: genai.TypeArray,
+			Items: &genai.Schema{
+				Type:     genai.TypeObject,
+				Required: []string{"card", "kind", "text"},
+				Properties: map[string]*genai.Schema{
+					"card": {Type: genai.TypeInteger},
+					"kind": {Type: genai.TypeString},
+					"text": {Type: genai.TypeString},
+				},
+			},
+		},
+	},
+}
+
+// Gemini generates cards and embeddings with Google's API.
+type Gemini struct {
+	client *genai.Client
+	model  *genai.GenerativeModel
+	// the same model without JSON mode, for answers in prose
+	text *genai.GenerativeModel
+	// JSON mode with mnemonicSchema
+	mnemonic *genai.GenerativeModel
+	embedder *genai.EmbeddingModel
+}
+
+func NewGemini(key, model string) (*Gemini, error) {
+	ctx := context.TODO()
+	client, err := genai.NewClient(ctx, option.WithAPIKey(key))
+	if err != nil {
+		return nil, fmt.Errorf("Error on creating AI client :%v", err)
+	}
+	if model == "" {
+		model = defaultGeminiModel
+	}
+
+	generative := client.GenerativeModel(model)
+	//JSON mode with the schema, the model can't answer with anything else
+	generative.ResponseMIMEType = "application/json"
+	generative.ResponseSchema = geminiCardSchema
+
+	mnemonic := client.GenerativeModel(model)
+	mnemonic.ResponseMIMEType = "application/json"
+	mnemonic.ResponseSchema = geminiMnemonicSchema
+
+	return &Gemini{model: generative, text: client.GenerativeModel(model), mnemonic: mnemonic, client: client, embedder: client.EmbeddingModel(embeddingModel)}, nil
+}
+
+func (s *Gemini) GenerateCardsFromFile(ctx context.Context, file io.Reader, figures []Figure, opts Options) (*FlashCardResponse, error) {
+	prompt, err := RenderPrompt(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	//Upload to Server
+	sFile, err := s.client.UploadFile(ctx, "", file, nil)
+	if err != nil {
+		log.Println(err)
+		return nil, err
+	}
+	defer s.client.DeleteFile(ctx, sFile.Name)
+
+	return s.generate(ctx, geminiParts(genai.FileData{URI: sFile.URI}, figures, prompt))
+}
+
+// GenerateCardsFromText is GenerateCardsFromFile for material that is already text, like a markdown note.
+func (s *Gemini) GenerateCardsFromText(ctx context.Context, text string, figures []Figure, opts Options) (*FlashCardResponse, error) {
+	prompt, err := RenderPrompt(opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.generate(ctx, geminiParts(genai.Text(text), figures, prompt))
+}
+
+func (s *Gemini) GenerateCardsFromTopic(ctx context.Context, topic string, opts Options) (*FlashCardResponse, error) {
+	return s.generate(ctx, []genai.Part{genai.Text(topicPrompt(topic, opts))})
+}
+
+func (s *Gemini) RewriteCard(ctx context.Context, req RewriteRequest) (*FlashCardResponse, error) {
+	resp, err := s.generate(ctx, []genai.Part{genai.Text(rewritePrompt(req))})
+	if err != nil {
+		return nil, err
+	}
+	return rewriteResult(req, resp)
+}
+
+func (s *Gemini) Assist(ctx context.Context, req AssistRequest) (string, error) {
+	text, err := s.prose(ctx, assistPrompt(req))
+	if err != nil {
+		return "", err
+	}
+	return assistResult(req, text)
+}
+
+func (s *Gemini) Chat(ctx context.Context, req ChatRequest) (string, error) {
+	text, err := s.prose(ctx, chatPrompt(req))
+	if err != nil {
+		return "", err
+	}
+	return chatResult(text)
+}
+
+// prose asks for a free text answer, without JSON mode.
+func (s *Gemini) prose(ctx context.Context, prompt string) (string, error) {
+	resp, err := s.text.GenerateContent(ctx, genai.Text(prompt))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("empty response from the model")
+	}
+	text, ok := resp.Candidates[0].Content.Parts[0].(genai.Text)
+	if !ok {
+		return "", fmt.Errorf("error on formating. response doesnt contain text")
+	}
+	return string(text), nil
+}
+
+func (s *Gemini) Mnemonics(ctx context.Context, cards []MnemonicCard) ([]Mnemonic, error) {
+	var mnemonics []Mnemonic
+	err := askChecked(ctx, func(ctx context.Context, retry string) (string, error) {
+		request := []genai.Part{genai.Text(mnemonicPrompt(cards))}
+		if retry != "" {
+			request = append(request, genai.Text(retry))
+		}
+		resp, err := s.mnemonic.GenerateContent(ctx, request...)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+			return "", fmt.Errorf("empty response from the model")
+		}
+		return formatLLMResponse(resp.Candidates[0].Content.Parts[0])
+	}, func(text string) error {
+		var err error
+		mnemonics, err = parseMnemonics(text, len(cards))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mnemonics, nil
+}
+
+func (s *Gemini) generate(ctx context.Context, parts []genai.Part) (*FlashCardResponse, error) {
+	return generateChecked(ctx, func(ctx context.Context, retry string) (string, error) {
+		request := parts
+		if retry != "" {
+			request = append(parts[:len(parts):len(parts)], genai.Text(retry))
+		}
+		resp, err := s.model.GenerateContent(ctx, request...)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil || len(resp.Candidates[0].Content.Parts) == 0 {
+			return "", fmt.Errorf("empty response from the model")
+		}
+		return formatLLMResponse(resp.Candidates[0].Content.Parts[0])
+	})
+}
+
+// geminiParts puts the labeled figures and the prompt after the material.
+func geminiParts(material genai.Part, figures []Figure, prompt string) []genai.Part {
+	parts := []genai.Part{material}
+	for _, figure := range figures {
+		parts = append(parts, genai.Text(fmt.Sprintf("Figure %d (page %d)", figure.Number, figure.Page)), genai.ImageData(figure.Format, figure.Data))
+	}
+	return append(parts, genai.Text(prompt))
+}
+
+func formatLLMResponse(resp genai.Part) (string, error) {
+	var llmText genai.Text
+	var ok bool
+	if llmText, ok = resp.(genai.Text); !ok {
+		return "", fmt.Errorf("error on formating. response doesnt contain text")
+	}
+	return trimJSON(string(llmText)), nil
+}
@@ -5,98 +5,117 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"strings"
-
-	"github.com/google/generative-ai-go/genai"
-	"google.golang.org/api/option"
 )
 
-const cueCardPrompt = `
-You are an AI assistant helping to create cue cards from study material.
-The user has uploaded a file containing educational content (lecture notes, textbook sections, or reference material). Read and analyze the file content carefully.
-Your task is to extract important and detailed concepts, definitions, and explanations, and format them as cue cards in JSON.
-Each cue card should have:
-- A front field: a question or prompt (e.g. "Define polymorphism", or "What is the purpose of TCP?")
-- A back field: a clear and complete answer or explanation.
-Ensure you:
-- Do not skip technical details or nuance
-- Break down long material into multiple cards if needed
-- Cover all major concepts from the file
-- Return valid JSON only, no code blocks or extra formatting
-
-Format your response like this:
-{
-  "cards": [
-    {
-      "front": "What is ___?",
-      "back": "..."
-    },
-    ...
-  ]
-}
-`
-
-type LLMService struct {
-	client *genai.Client
-	model  *genai.GenerativeModel
+// CardGenerator turns study material into cards. The worker only depends on
+// this, the backend is picked by Config.
+type CardGenerator interface {
+	// GenerateCardsFromFile reads a PDF, figures are images taken from it the cards may refer to.
+	GenerateCardsFromFile(ctx context.Context, file io.Reader, figures []Figure, opts Options) (*FlashCardResponse, error)
+	// GenerateCardsFromText is for material that is text already, like a chunk of a long file
+	GenerateCardsFromText(ctx context.Context, text string, figures []Figure, opts Options) (*FlashCardResponse, error)
+	// GenerateCardsFromTopic writes cards about a topic from what the model knows, there is no material
+	GenerateCardsFromTopic(ctx context.Context, topic string, opts Options) (*FlashCardResponse, error)
+	// RewriteCard proposes a new version of one card, or several cards for RewriteSplit
+	RewriteCard(ctx context.Context, req RewriteRequest) (*FlashCardResponse, error)
+	// Assist writes a hint or an explanation of a card, as Markdown
+	Assist(ctx context.Context, req AssistRequest) (string, error)
+	// Mnemonics writes a mnemonic for each card, at most MnemonicBatch at once
+	Mnemonics(ctx context.Context, cards []MnemonicCard) ([]Mnemonic, error)
+	// Chat answers a question from the passages of req, citing them as [n]
+	Chat(ctx context.Context, req ChatRequest) (string, error)
 }
 
 type Card struct {
 	Front string
 	Back  string
+	// where in the uploaded file the card comes from, zero when the model didn't say
+	PageStart int    `json:"page_start"`
+	PageEnd   int    `json:"page_end"`
+	Source    string `json:"source"`
+	// number of the attached Figure the card shows, 0 for none
+	Figure int `json:"figure"`
+}
+
+// Figure is an image extracted from the uploaded file, sent along so cards can refer to it.
+type Figure struct {
+	Number int
+	Page   int
+	Format string
+	Data   []byte
 }
 
 type FlashCardResponse struct {
 	Cards []Card
 }
 
-func New(key string) *LLMService {
-	ctx := context.TODO()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(key))
-	if err != nil {
-		log.Fatalf("Error on creating AI client :%v", err)
-	}
-
-	return &LLMService{model: CreateModel(client), client: client}
+const (
+	ProviderGemini = "gemini"
+	// any server speaking the OpenAI chat API: OpenAI, Ollama, llama.cpp, vLLM...
+	ProviderOpenAI = "openai"
+	// canned cards and hashed embeddings, no model needed
+	ProviderFake = "fake"
+)
 
+type Config struct {
+	Provider string
+	Key      string
+	// chat model, the provider's default when empty
+	Model string
+	// root of the OpenAI compatible API, like http://localhost:11434/v1
+	BaseURL        string
+	EmbeddingModel string
 }
-func CreateModel(client *genai.Client) *genai.GenerativeModel {
-	model := client.GenerativeModel("gemini-1.5-flash")
-	return model
-}
-
-func (s *LLMService) GenerateCardsFromFile(ctx context.Context, file io.Reader) (*FlashCardResponse, error) {
-
-	//Upload to Server
-	sFile, err := s.client.UploadFile(ctx, "", file, nil)
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-	defer s.client.DeleteFile(ctx, sFile.Name)
-
-	//LLM Request
-	resp, err := s.model.GenerateContent(ctx, genai.FileData{URI: sFile.URI}, genai.Text(cueCardPrompt))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
-
-	//polish response
-	polishedResp, err := formatLLMResponse((resp.Candidates[0].Content.Parts[0]))
-	if err != nil {
-		log.Println(err)
-		return nil, err
-	}
 
-	//format to Json objects
-	flashCards, err := convertRespToStruct(polishedResp)
-	if err != nil {
-		log.Printf("error on converting to structs: %v \n", err)
+// New creates the card generator and embedder of the configured provider.
+func New(cfg Config) (CardGenerator, Embedder, error) {
+	switch cfg.Provider {
+	case "", ProviderGemini:
+		gemini, err := NewGemini(cfg.Key, cfg.Model)
+		if err != nil {
+			return nil, nil, err
+		}
+		return gemini, gemini, nil
+	case ProviderOpenAI:
+		//fake vectors would be stored as real ones and mix with them later
+		if cfg.EmbeddingModel == "" {
+			return nil, nil, fmt.Errorf("the openai provider needs LLM_EMBEDDING_MODEL set")
+		}
+		openai, err := NewOpenAI(cfg.BaseURL, cfg.Key, cfg.Model, cfg.EmbeddingModel)
+		if err != nil {
+			return nil, nil, err
+		}
+		return openai, openai, nil
+	case ProviderFake:
+		return FakeGenerator{}, FakeEmbedder{}, nil
 	}
-	return flashCards, nil
+	return nil, nil, fmt.Errorf("unknown llm provider %q", cfg.Provider)
+}
 
+// cardSchema is the JSON schema of a reply, for providers that can hold the model to it.
+var cardSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"cards"},
+	"properties": map[string]any{
+		"cards": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"front", "back", "page_start", "page_end", "source", "figure"},
+				"properties": map[string]any{
+					"front":      map[string]any{"type": "string"},
+					"back":       map[string]any{"type": "string"},
+					"page_start": map[string]any{"type": "integer"},
+					"page_end":   map[string]any{"type": "integer"},
+					"source":     map[string]any{"type": "string"},
+					"figure":     map[string]any{"type": "integer"},
+				},
+			},
+		},
+	},
 }
 
 func convertRespToStruct(resp string) (*FlashCardResponse, error) {
@@ -108,16 +127,10 @@ func convertRespToStruct(resp string) (*FlashCardResponse, error) {
 	return &flashCards, nil
 }
 
-func formatLLMResponse(resp genai.Part) (string, error) {
-	var llmText genai.Text
-	var ok bool
-	if llmText, ok = resp.(genai.Text); !ok {
-		return "", fmt.Errorf("error on formating. response doesnt contain text")
-	}
-	text := string(llmText)
+// trimJSON drops the code fence models like to wrap JSON in.
+func trimJSON(text string) string {
 	text = strings.TrimSpace(text)
 	text = strings.TrimPrefix(text, "```json")
 	text = strings.TrimSuffix(text, "```")
-	text = strings.TrimSpace(text)
-	return text, nil
+	return strings.TrimSpace(text)
 }
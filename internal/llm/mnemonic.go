@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// the first letters of the key terms, spelling a word or a sentence
+	MnemonicAcronym = "acronym"
+	// a vivid image linking the question to the answer
+	MnemonicAssociation = "association"
+	MnemonicRhyme       = "rhyme"
+	MnemonicStory       = "story"
+)
+
+// cards sent to the model in one request
+const MnemonicBatch = 20
+
+// longest mnemonic kept, a longer one is no easier than the card
+const maxMnemonicLength = 500
+
+// MnemonicCard is a card the user keeps forgetting, as plain text.
+type MnemonicCard struct {
+	Front string
+	Back  string
+}
+
+// Mnemonic is written for Cards[Card-1] of the request.
+type Mnemonic struct {
+	Card int    `json:"card"`
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+type mnemonicResponse struct {
+	Mnemonics []Mnemonic `json:"mnemonics"`
+}
+
+// mnemonicSchema is the JSON schema of a reply, like cardSchema.
+var mnemonicSchema = map[string]any{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"mnemonics"},
+	"properties": map[string]any{
+		"mnemonics": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"card", "kind", "text"},
+				"properties": map[string]any{
+					"card": map[string]any{"type": "integer"},
+					"kind": map[string]any{"type": "string", "enum": []string{MnemonicAcronym, MnemonicAssociation, MnemonicRhyme, MnemonicStory}},
+					"text": map[string]any{"type": "string"},
+				},
+			},
+		},
+	},
+}
+
+func mnemonicPrompt(cards []MnemonicCard) string {
+	var b strings.Builder
+	b.WriteString(`You are helping a student who keeps forgetting the answers of these cue cards.
+Write one mnemonic for each card that makes its answer easier to remember: an acronym of the key terms,
+a vivid image or association linking question and answer, a short rhyme or a tiny story.
+Keep each under 300 characters, write it in the language of the card and don't change the facts.
+
+Cards:
+`)
+	for i, card := range cards {
+		fmt.Fprintf(&b, "%d. Front: %s\n   Back: %s\n", i+1, card.Front, card.Back)
+	}
+	b.WriteString(`
+Each mnemonic is a JSON object with the number of its card, its kind (acronym, association, rhyme or story) and its text.
+Return valid JSON only, do not wrap the whole response in a code block:
+{"mnemonics": [{"card": 1, "kind": "acronym", "text": "..."}]}
+`)
+	return b.String()
+}
+
+// parseMnemonics keeps one valid mnemonic per card. A reply with none is an error.
+func parseMnemonics(text string, cards int) ([]Mnemonic, error) {
+	var resp mnemonicResponse
+	err := json.Unmarshal([]byte(trimJSON(text)), &resp)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	seen := make(map[int]bool)
+	var mnemonics []Mnemonic
+	for _, m := range resp.Mnemonics {
+		m.Text = strings.TrimSpace(m.Text)
+		if m.Card < 1 || m.Card > cards || seen[m.Card] || m.Text == "" || len(m.Text) > maxMnemonicLength || m.Text == "..." {
+			continue
+		}
+		switch m.Kind {
+		case MnemonicAcronym, MnemonicAssociation, MnemonicRhyme, MnemonicStory:
+		default:
+			m.Kind = MnemonicAssociation
+		}
+		seen[m.Card] = true
+		mnemonics = append(mnemonics, m)
+	}
+	if len(mnemonics) == 0 && len(resp.Mnemonics) > 0 {
+		return nil, fmt.Errorf("no valid mnemonics")
+	}
+	return mnemonics, nil
+}
@@ -0,0 +1,281 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// OpenAI talks to any server implementing the OpenAI chat and embeddings API,
+// so local Ollama or llama.cpp servers work as well as the hosted one.
+type OpenAI struct {
+	baseURL        string
+	key            string
+	model          string
+	embeddingModel string
+	client         *http.Client
+}
+
+type chatMessage struct {
+	Role string `json:"role"`
+	// a string, or a list of parts when images are sent along
+	Content any `json:"content"`
+}
+
+type chatPart struct {
+	Type     string        `json:"type"`
+	Text     string        `json:"text,omitempty"`
+	ImageURL *chatImageURL `json:"image_url,omitempty"`
+}
+
+type chatImageURL struct {
+	URL string `json:"url"`
+}
+
+type chatRequest struct {
+	Model          string        `json:"model"`
+	Messages       []chatMessage `json:"messages"`
+	ResponseFormat any           `json:"response_format,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func NewOpenAI(baseURL, key, model, embeddingModel string) (*OpenAI, error) {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	if model == "" {
+		return nil, fmt.Errorf("a model is needed for the openai provider")
+	}
+	return &OpenAI{
+		baseURL:        strings.TrimSuffix(baseURL, "/"),
+		key:            key,
+		model:          model,
+		embeddingModel: embeddingModel,
+		//local models on a CPU take minutes for a long file
+		client: &http.Client{Timeout: 10 * time.Minute},
+	}, nil
+}
+
+// GenerateCardsFromFile sends the text of the PDF, the chat API takes no files.
+func (s *OpenAI) GenerateCardsFromFile(ctx context.Context, file io.Reader, figures []Figure, opts Options) (*FlashCardResponse, error) {
+	text, err := pdfText(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	return s.GenerateCardsFromText(ctx, text, figures, opts)
+}
+
+// GenerateCardsFromText sends figures as images, which only vision models understand.
+func (s *OpenAI) GenerateCardsFromText(ctx context.Context, text string, figures []Figure, opts Options) (*FlashCardResponse, error) {
+	prompt, err := RenderPrompt(opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(figures) == 0 {
+		return s.generate(ctx, text+"\n\n"+prompt)
+	}
+
+	parts := []chatPart{{Type: "text", Text: text}}
+	for _, figure := range figures {
+		url := fmt.Sprintf("data:image/%s;base64,%s", figure.Format, base64.StdEncoding.EncodeToString(figure.Data))
+		parts = append(parts,
+			chatPart{Type: "text", Text: fmt.Sprintf("Figure %d (page %d)", figure.Number, figure.Page)},
+			chatPart{Type: "image_url", ImageURL: &chatImageURL{URL: url}},
+		)
+	}
+	parts = append(parts, chatPart{Type: "text", Text: prompt})
+	return s.generate(ctx, parts)
+}
+
+func (s *OpenAI) GenerateCardsFromTopic(ctx context.Context, topic string, opts Options) (*FlashCardResponse, error) {
+	return s.generate(ctx, topicPrompt(topic, opts))
+}
+
+func (s *OpenAI) RewriteCard(ctx context.Context, req RewriteRequest) (*FlashCardResponse, error) {
+	resp, err := s.generate(ctx, rewritePrompt(req))
+	if err != nil {
+		return nil, err
+	}
+	return rewriteResult(req, resp)
+}
+
+func (s *OpenAI) Assist(ctx context.Context, req AssistRequest) (string, error) {
+	text, err := s.prose(ctx, assistPrompt(req))
+	if err != nil {
+		return "", err
+	}
+	return assistResult(req, text)
+}
+
+func (s *OpenAI) Chat(ctx context.Context, req ChatRequest) (string, error) {
+	text, err := s.prose(ctx, chatPrompt(req))
+	if err != nil {
+		return "", err
+	}
+	return chatResult(text)
+}
+
+// prose asks for a free text answer, without a response format.
+func (s *OpenAI) prose(ctx context.Context, prompt string) (string, error) {
+	var resp chatResponse
+	err := s.post(ctx, "/chat/completions", chatRequest{Model: s.model, Messages: []chatMessage{{Role: "user", Content: prompt}}}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty response from the model")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (s *OpenAI) Mnemonics(ctx context.Context, cards []MnemonicCard) ([]Mnemonic, error) {
+	var mnemonics []Mnemonic
+	err := askChecked(ctx, s.jsonCompletion(mnemonicPrompt(cards), "mnemonics", mnemonicSchema), func(text string) error {
+		var err error
+		mnemonics, err = parseMnemonics(text, len(cards))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mnemonics, nil
+}
+
+func (s *OpenAI) generate(ctx context.Context, content any) (*FlashCardResponse, error) {
+	return generateChecked(ctx, s.jsonCompletion(content, "flashcards", cardSchema))
+}
+
+// jsonCompletion asks for a reply in the given schema. A retry follows the
+// rejected reply, so the model sees what it has to correct.
+func (s *OpenAI) jsonCompletion(content any, name string, schema map[string]any) completion {
+	rejected := ""
+	return func(ctx context.Context, retry string) (string, error) {
+		req := chatRequest{
+			Model:    s.model,
+			Messages: []chatMessage{{Role: "user", Content: content}},
+			ResponseFormat: map[string]any{
+				"type":        "json_schema",
+				"json_schema": map[string]any{"name": name, "strict": true, "schema": schema},
+			},
+		}
+		if retry != "" {
+			req.Messages = append(req.Messages,
+				chatMessage{Role: "assistant", Content: rejected},
+				chatMessage{Role: "user", Content: retry},
+			)
+		}
+		var resp chatResponse
+		err := s.post(ctx, "/chat/completions", req, &resp)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("empty response from the model")
+		}
+		rejected = resp.Choices[0].Message.Content
+		return rejected, nil
+	}
+}
+
+func (s *OpenAI) EmbeddingModel() string {
+	return s.embeddingModel
+}
+
+func (s *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += maxEmbeddingBatch {
+		end := min(start+maxEmbeddingBatch, len(texts))
+
+		var resp embeddingResponse
+		err := s.post(ctx, "/embeddings", embeddingRequest{Model: s.embeddingModel, Input: texts[start:end]}, &resp)
+		if err != nil {
+			return nil, fmt.Errorf("error on embedding texts: %v", err)
+		}
+		if len(resp.Data) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(resp.Data))
+		}
+		//the order of data isn't promised, index is
+		batch := make([][]float32, end-start)
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(batch) {
+				return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+			}
+			batch[d.Index] = d.Embedding
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (s *OpenAI) post(ctx context.Context, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.key != "" {
+		req.Header.Set("Authorization", "Bearer "+s.key)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// pdfText extracts the text of a PDF with pdftotext, pages are separated by form feeds.
+func pdfText(ctx context.Context, file io.Reader) (string, error) {
+	tmp, err := os.CreateTemp("", "cuemind-text-*.pdf")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, file)
+	tmp.Close()
+	if err != nil {
+		return "", err
+	}
+
+	out, err := exec.CommandContext(ctx, "pdftotext", "-layout", tmp.Name(), "-").Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot extract text from pdf: %v", err)
+	}
+	return string(out), nil
+}
@@ -0,0 +1,313 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode"
+)
+
+const (
+	DifficultyEasy   = "easy"
+	DifficultyMedium = "medium"
+	DifficultyHard   = "hard"
+)
+
+const (
+	// "Define polymorphism"
+	StyleDefinition = "definition"
+	// "Why does TCP need a handshake?"
+	StyleWhyHow = "why_how"
+	// "Which data structure would you use for ...?"
+	StyleApplication = "application"
+)
+
+const (
+	CardBasic = "basic"
+	// a sentence with the key term blanked out on the front, the term on the back
+	CardCloze = "cloze"
+)
+
+// more cards than this in one request aren't worth waiting for
+const maxCardCount = 200
+
+// Options steer what cards the model writes. The zero value lets it decide.
+type Options struct {
+	// cards wanted, 0 for as many as the material needs
+	Count      int    `json:"count"`
+	Difficulty string `json:"difficulty"`
+	Style      string `json:"style"`
+	// language the cards are written in, the material's own when empty
+	Language string `json:"language"`
+	CardType string `json:"card_type"`
+	// also write cards for concepts the collection has cards for already
+	IncludeKnown bool `json:"include_known"`
+
+	// the collection's prompt template, DefaultTemplate when empty
+	Template string `json:"-"`
+	// fronts of cards the collection has, newest first, for templates that list them
+	ExistingCards []string `json:"-"`
+}
+
+// Validate reports the first option a client got wrong.
+func (o Options) Validate() error {
+	if o.Count < 0 || o.Count > maxCardCount {
+		return fmt.Errorf("count must be between 0 and %d", maxCardCount)
+	}
+	switch o.Difficulty {
+	case "", DifficultyEasy, DifficultyMedium, DifficultyHard:
+	default:
+		return fmt.Errorf("difficulty must be easy, medium or hard")
+	}
+	switch o.Style {
+	case "", StyleDefinition, StyleWhyHow, StyleApplication:
+	default:
+		return fmt.Errorf("style must be definition, why_how or application")
+	}
+	switch o.CardType {
+	case "", CardBasic, CardCloze:
+	default:
+		return fmt.Errorf("card_type must be basic or cloze")
+	}
+	//the language goes into the prompt, keep it a name
+	if len(o.Language) > 40 || strings.IndexFunc(o.Language, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != ' ' && r != '-' && r != '(' && r != ')'
+	}) >= 0 {
+		return fmt.Errorf("language must be a language name like English or Deutsch")
+	}
+	return nil
+}
+
+// DefaultTemplate is the prompt of collections without one of their own. Templates
+// are Go text/template with Options as data, so {{.Count}}, {{.Language}} or
+// {{range .ExistingCards}} work. The fields a reply needs are added after them.
+const DefaultTemplate = `You are an AI assistant helping to create cue cards from study material.
+The user has uploaded a file containing educational content (lecture notes, textbook sections, or reference material). Read and analyze the file content carefully.
+Your task is to extract important and detailed concepts, definitions, and explanations, and format them as cue cards.
+{{- if eq .CardType "cloze"}}
+The front of a card is one sentence from the material's ideas with its key term replaced by "____" (e.g. "____ lets one interface have many implementations").
+The back is the missing term, followed by one sentence of context if it helps.
+{{- else}}
+The front of a card is a question or prompt (e.g. "Define polymorphism", or "What is the purpose of TCP?").
+The back is a clear and complete answer or explanation.
+{{- end}}
+Ensure you:
+- Do not skip technical details or nuance
+{{- if .Count}}
+- Write exactly {{.Count}} cards, picking the most important concepts
+{{- else}}
+- Break down long material into multiple cards if needed
+- Cover all major concepts from the file
+{{- end}}
+{{- if eq .Difficulty "easy"}}
+- Keep the cards introductory: core terms and facts a beginner has to know first
+{{- else if eq .Difficulty "medium"}}
+- Aim at a student who knows the basics: connections between concepts, not only terms
+{{- else if eq .Difficulty "hard"}}
+- Aim at exam level: edge cases, subtle distinctions, multi-step reasoning and derivations
+{{- end}}
+{{- if eq .Style "definition"}}
+- Ask for definitions: "What is ...?", "Define ..."
+{{- else if eq .Style "why_how"}}
+- Ask why and how things work: causes, mechanisms, reasons, not bare definitions
+{{- else if eq .Style "application"}}
+- Ask the learner to apply the material: short scenarios, worked problems, choosing the right concept for a case
+{{- end}}
+{{- if .Language}}
+- Write front and back in {{.Language}}, whatever the language of the material; keep the source quote as it is in the file
+{{- end}}
+- Write front and back in Markdown: formulas in LaTeX between $...$ (inline) or $$...$$ (display), code in fenced blocks with the language name
+`
+
+// the reply format, the same whatever template a collection uses
+const outputInstructions = `
+Each cue card is a JSON object with:
+- front and back fields: the two sides of the card as described above.
+- page_start and page_end fields: the page numbers of the file the card was taken from.
+- A source field: a short verbatim quote (at most two sentences) from the file that supports the answer.
+- A figure field: the number of the attached figure the card is about, or 0.
+Escape backslashes and newlines inside JSON strings (write \\frac, not \frac).
+Return valid JSON only, do not wrap the whole response in a code block.
+
+Text material may mark where pages start with [Page N], use those numbers for page_start and page_end.
+
+Figures extracted from the file may be attached after it, each labeled "Figure N (page P)".
+For diagrams, charts and labeled illustrations, add cards about the figure: ask the learner to name a labeled part,
+explain what the diagram shows or read a value from the chart, and set figure to its number.
+The figure is shown with the card, so don't describe it in the answer. Skip logos and decorative images.
+
+Format your response like this:
+{
+  "cards": [
+    {
+      "front": "What is ___?",
+      "back": "...",
+      "page_start": 12,
+      "page_end": 12,
+      "source": "...",
+      "figure": 0
+    },
+    ...
+  ]
+}
+`
+
+// longest template a collection can save
+const maxTemplateLength = 20000
+
+// longest text a template may render, the known cards and reply format come on top
+const maxPromptLength = 60000
+
+// existing cards shown to a template, enough to steer clear of repeats
+const MaxExistingCards = 200
+
+// the summary of known cards sent for gap-aware generation, in characters
+const (
+	maxKnownCardsLength = 8000
+	maxKnownCardLength  = 100
+)
+
+var defaultTemplate = template.Must(template.New("cards").Parse(DefaultTemplate))
+
+// ParseTemplate checks a custom template and that it renders with sample options.
+func ParseTemplate(body string) (*template.Template, error) {
+	if len(body) > maxTemplateLength {
+		return nil, fmt.Errorf("template is longer than %d characters", maxTemplateLength)
+	}
+	tmpl, err := template.New("cards").Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	err = checkNodes(tmpl.Tree, tmpl.Root, false)
+	if err != nil {
+		return nil, err
+	}
+	sample := Options{Count: 10, Difficulty: DifficultyMedium, Style: StyleDefinition, Language: "English", CardType: CardBasic, ExistingCards: []string{"What is a sample card?"}}
+	err = tmpl.Execute(&limitedWriter{left: maxPromptLength}, sample)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// checkNodes keeps the output of a template in proportion to its options: a
+// range only walks a field, never inside another range, and other templates
+// can't be called, so nothing repeats or recurses without bound.
+func checkNodes(tree *parse.Tree, node parse.Node, inRange bool) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			err := checkNodes(tree, child, inRange)
+			if err != nil {
+				return err
+			}
+		}
+	case *parse.RangeNode:
+		if inRange {
+			return fmt.Errorf("%s: range inside a range is not allowed", location(tree, n))
+		}
+		if len(n.Pipe.Cmds) != 1 || len(n.Pipe.Cmds[0].Args) != 1 || n.Pipe.Cmds[0].Args[0].Type() != parse.NodeField {
+			return fmt.Errorf("%s: range can only walk a field like .ExistingCards", location(tree, n))
+		}
+		err := checkNodes(tree, n.List, true)
+		if err != nil {
+			return err
+		}
+		return checkNodes(tree, n.ElseList, inRange)
+	case *parse.IfNode:
+		err := checkNodes(tree, n.List, inRange)
+		if err != nil {
+			return err
+		}
+		return checkNodes(tree, n.ElseList, inRange)
+	case *parse.WithNode:
+		err := checkNodes(tree, n.List, inRange)
+		if err != nil {
+			return err
+		}
+		return checkNodes(tree, n.ElseList, inRange)
+	case *parse.TemplateNode:
+		return fmt.Errorf("%s: calling templates is not allowed", location(tree, n))
+	}
+	return nil
+}
+
+// location is where node is in the template, as line:column.
+func location(tree *parse.Tree, node parse.Node) string {
+	loc, _ := tree.ErrorContext(node)
+	return strings.TrimPrefix(loc, tree.ParseName+":")
+}
+
+// limitedWriter collects a rendered prompt and fails once it grows past left bytes.
+type limitedWriter struct {
+	out  strings.Builder
+	left int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if len(p) > w.left {
+		return 0, fmt.Errorf("prompt is longer than %d characters", maxPromptLength)
+	}
+	w.left -= len(p)
+	return w.out.Write(p)
+}
+
+// RenderPrompt builds the instructions sent after the material, from the
+// collection's template when opts has one.
+func RenderPrompt(opts Options) (string, error) {
+	tmpl := defaultTemplate
+	if opts.Template != "" {
+		var err error
+		tmpl, err = ParseTemplate(opts.Template)
+		if err != nil {
+			return "", fmt.Errorf("invalid prompt template: %v", err)
+		}
+	}
+	out := &limitedWriter{left: maxPromptLength}
+	err := tmpl.Execute(out, opts)
+	if err != nil {
+		return "", fmt.Errorf("cannot render prompt template: %v", err)
+	}
+	prompt := strings.TrimRight(out.out.String(), "\n") + "\n"
+	return prompt + knownCardsNote(opts) + outputInstructions, nil
+}
+
+// knownCardsNote tells the model which cards to leave out, empty when opts
+// includes known concepts or the collection has no cards.
+func knownCardsNote(opts Options) string {
+	known := knownCards(opts.ExistingCards)
+	if opts.IncludeKnown || len(known) == 0 {
+		return ""
+	}
+	return "\nThe collection already has cards asking:\n- " + strings.Join(known, "\n- ") + "\n" +
+		"Only write cards for concepts these don't cover, even if that means fewer cards or none.\n"
+}
+
+// knownCards is a compact summary of existing fronts: shortened, without repeats
+// and cut off at maxKnownCardsLength, the newest cards first.
+func knownCards(fronts []string) []string {
+	var known []string
+	seen := make(map[string]bool, len(fronts))
+	length := 0
+	for _, front := range fronts {
+		front = strings.Join(strings.Fields(front), " ")
+		if runes := []rune(front); len(runes) > maxKnownCardLength {
+			front = string(runes[:maxKnownCardLength]) + "…"
+		}
+		key := strings.ToLower(front)
+		if front == "" || seen[key] {
+			continue
+		}
+		if length+len(front) > maxKnownCardsLength {
+			break
+		}
+		seen[key] = true
+		length += len(front)
+		known = append(known, front)
+	}
+	return known
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "default", body: DefaultTemplate},
+		{name: "range over cards", body: "{{range $i, $c := .ExistingCards}}{{$i}} {{$c}}\n{{else}}none{{end}}"},
+		{name: "nested range", body: "{{range .ExistingCards}}{{range $.ExistingCards}}x{{end}}{{end}}", wantErr: "1:32: range inside a range"},
+		{name: "nested in if", body: "{{range .ExistingCards}}{{if .}}{{range $.ExistingCards}}{{end}}{{end}}{{end}}", wantErr: "range inside a range"},
+		{name: "range over number", body: "{{range 1000000000}}{{end}}", wantErr: "range can only walk a field"},
+		{name: "recursion", body: `{{define "x"}}{{template "x" .}}{{end}}{{template "x" .}}`, wantErr: "calling templates is not allowed"},
+		{name: "syntax", body: "{{if}}", wantErr: "missing value for if"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTemplate(tt.body)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("got %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRenderPromptCapped(t *testing.T) {
+	body := "{{range .ExistingCards}}" + strings.Repeat("x", 1000) + "{{end}}"
+	opts := Options{Template: body, IncludeKnown: true, ExistingCards: make([]string, MaxExistingCards)}
+	_, err := RenderPrompt(opts)
+	if err == nil || !strings.Contains(err.Error(), "prompt is longer than") {
+		t.Fatalf("got %v, want a length error", err)
+	}
+
+	opts.ExistingCards = opts.ExistingCards[:3]
+	prompt, err := RenderPrompt(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(prompt, strings.Repeat("x", 3000)+"\n") || !strings.HasSuffix(prompt, outputInstructions) {
+		t.Errorf("unexpected prompt %.40q", prompt)
+	}
+}
+
+func TestTopicPrompt(t *testing.T) {
+	opts := Options{Count: 5, CardType: CardCloze, Language: "Deutsch", Template: "{{.Count}} custom", ExistingCards: []string{"What is ATP?"}}
+	prompt := topicPrompt("cell energy", opts)
+	for _, want := range []string{`topic: "cell energy"`, "exactly 5 cards", `"____"`, "in Deutsch", "- What is ATP?"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt misses %q", want)
+		}
+	}
+	for _, unwanted := range []string{"custom", "uploaded", "[Page N]"} {
+		if strings.Contains(prompt, unwanted) {
+			t.Errorf("prompt has %q", unwanted)
+		}
+	}
+}
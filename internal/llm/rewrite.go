@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// shorter, plainer wording of the same card
+	RewriteSimplify = "simplify"
+	// one card per fact of the original
+	RewriteSplit = "split"
+	// the answer with a concrete example added
+	RewriteExample = "add_example"
+	// the card with its factual errors corrected
+	RewriteFix = "fix_error"
+	// a new card about the same concept
+	RewriteRegenerate = "regenerate"
+)
+
+// longest note a user can add to a rewrite
+const maxRewriteInstructions = 1000
+
+// RewriteRequest is a card to rewrite and what to do with it.
+type RewriteRequest struct {
+	Action string
+	Front  string
+	Back   string
+	// the quote of the material the card was made from, if any
+	Source string
+	// what the user wants changed, like the error to fix
+	Instructions string
+}
+
+// Validate reports the first field a client got wrong.
+func (r RewriteRequest) Validate() error {
+	switch r.Action {
+	case RewriteSimplify, RewriteSplit, RewriteExample, RewriteFix, RewriteRegenerate:
+	default:
+		return fmt.Errorf("action must be simplify, split, add_example, fix_error or regenerate")
+	}
+	if len(r.Instructions) > maxRewriteInstructions {
+		return fmt.Errorf("instructions must be at most %d characters", maxRewriteInstructions)
+	}
+	return nil
+}
+
+var rewriteTasks = map[string]string{
+	RewriteSimplify: `Rewrite the card so it is easier to learn: shorter, plainer words, one idea.
+Keep the meaning and everything that is needed for a correct answer. Return exactly one card.`,
+	RewriteSplit: `Split the card into atomic cards, each asking about exactly one fact or idea.
+Together the new cards cover everything the original card does. Return two or more cards,
+or the card unchanged if it can't be split.`,
+	RewriteExample: `Keep the front as it is and add a short, concrete example to the back that shows the answer in use.
+Return exactly one card.`,
+	RewriteFix: `Check the card for factual errors and correct them, keeping what is right as it is.
+If nothing is wrong, return the card unchanged. Return exactly one card.`,
+	RewriteRegenerate: `Write a better card about the same concept: a clear question and a complete answer.
+Return exactly one card.`,
+}
+
+// rewritePrompt builds the whole prompt of a rewrite, the card is its only material.
+func rewritePrompt(req RewriteRequest) string {
+	var b strings.Builder
+	b.WriteString("You are an AI assistant helping to improve a cue card of a student's deck.\n\n")
+	fmt.Fprintf(&b, "The card:\nFront: %s\nBack: %s\n", req.Front, req.Back)
+	if req.Source != "" {
+		fmt.Fprintf(&b, "It was made from this part of the study material: %q\n", req.Source)
+	}
+	b.WriteString("\n" + rewriteTasks[req.Action] + "\n")
+	if req.Instructions != "" {
+		fmt.Fprintf(&b, "The student adds: %s\n", req.Instructions)
+	}
+	b.WriteString("Write front and back in the language of the card.\n")
+	b.WriteString(`Write front and back in Markdown: formulas in LaTeX between $...$ (inline) or $$...$$ (display), code in fenced blocks with the language name.
+
+Each card is a JSON object with front and back fields, set page_start, page_end and figure to 0 and source to "".
+Escape backslashes and newlines inside JSON strings (write \\frac, not \frac).
+Return valid JSON only, do not wrap the whole response in a code block:
+{"cards": [{"front": "...", "back": "...", "page_start": 0, "page_end": 0, "source": "", "figure": 0}]}
+`)
+	return b.String()
+}
+
+// rewriteResult keeps one card for the actions that replace the card, a model
+// sometimes returns the original next to its rewrite.
+func rewriteResult(req RewriteRequest, resp *FlashCardResponse) (*FlashCardResponse, error) {
+	if len(resp.Cards) == 0 {
+		return nil, fmt.Errorf("the model returned no card")
+	}
+	if req.Action != RewriteSplit {
+		resp.Cards = resp.Cards[:1]
+	}
+	for i := range resp.Cards {
+		resp.Cards[i].PageStart, resp.Cards[i].PageEnd, resp.Cards[i].Figure, resp.Cards[i].Source = 0, 0, 0, ""
+	}
+	return resp, nil
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// topicPrompt builds the whole prompt of cards about a topic. There is no
+// material, so the collection's template, written for files, doesn't apply.
+func topicPrompt(topic string, opts Options) string {
+	var b strings.Builder
+	b.WriteString("You are an AI assistant helping a student learn a topic with cue cards.\n")
+	fmt.Fprintf(&b, "There is no study material, write the cards from what you know about this topic: %q\n\n", topic)
+	if opts.CardType == CardCloze {
+		b.WriteString(`The front of a card is one sentence about the topic with its key term replaced by "____".
+The back is the missing term, followed by one sentence of context if it helps.
+`)
+	} else {
+		b.WriteString("The front of a card is a question or prompt, the back a clear and complete answer.\n")
+	}
+	b.WriteString("Ensure you:\n- Stick to facts that are well established, leave out anything you are unsure about\n")
+	if opts.Count > 0 {
+		fmt.Fprintf(&b, "- Write exactly %d cards, picking the most important concepts of the topic\n", opts.Count)
+	} else {
+		b.WriteString("- Write as many cards as the core of the topic needs, about 10 to 20\n")
+	}
+	switch opts.Difficulty {
+	case DifficultyEasy:
+		b.WriteString("- Keep the cards introductory: core terms and facts a beginner has to know first\n")
+	case DifficultyMedium:
+		b.WriteString("- Aim at a student who knows the basics: connections between concepts, not only terms\n")
+	case DifficultyHard:
+		b.WriteString("- Aim at exam level: edge cases, subtle distinctions, multi-step reasoning and derivations\n")
+	}
+	switch opts.Style {
+	case StyleDefinition:
+		b.WriteString(`- Ask for definitions: "What is ...?", "Define ..."` + "\n")
+	case StyleWhyHow:
+		b.WriteString("- Ask why and how things work: causes, mechanisms, reasons, not bare definitions\n")
+	case StyleApplication:
+		b.WriteString("- Ask the learner to apply the topic: short scenarios, worked problems, choosing the right concept for a case\n")
+	}
+	if opts.Language != "" {
+		fmt.Fprintf(&b, "- Write front and back in %s\n", opts.Language)
+	} else {
+		b.WriteString("- Write front and back in the language of the topic description\n")
+	}
+	b.WriteString("- Write front and back in Markdown: formulas in LaTeX between $...$ (inline) or $$...$$ (display), code in fenced blocks with the language name\n")
+	b.WriteString(knownCardsNote(opts))
+	b.WriteString(`
+Each card is a JSON object with front and back fields, set page_start, page_end and figure to 0 and source to "".
+Escape backslashes and newlines inside JSON strings (write \\frac, not \frac).
+Return valid JSON only, do not wrap the whole response in a code block:
+{"cards": [{"front": "...", "back": "...", "page_start": 0, "page_end": 0, "source": "", "figure": 0}]}
+`)
+	return b.String()
+}
@@ -0,0 +1,179 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+// longest card sides kept, longer ones are the model pasting the material
+const (
+	maxFrontLength = 500
+	maxBackLength  = 3000
+)
+
+// a malformed reply is asked for again once
+const maxAttempts = 2
+
+// bits of the prompt a confused model copies into its cards
+var promptEchoes = []string{
+	"what is ___?",
+	"front field",
+	"back field",
+	"page_start",
+	"format your response",
+	"you are an ai assistant",
+}
+
+// completion returns the raw reply of the model. retry is empty on the first
+// try, after that it says what was wrong with the previous reply.
+type completion func(ctx context.Context, retry string) (string, error)
+
+// generateChecked asks the model until its reply holds valid cards. Errors of
+// the request itself are returned right away, only bad replies are asked again.
+func generateChecked(ctx context.Context, complete completion) (*FlashCardResponse, error) {
+	var flashCards *FlashCardResponse
+	err := askChecked(ctx, complete, func(text string) error {
+		var err error
+		flashCards, err = parseCards(text)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return flashCards, nil
+}
+
+// askChecked asks the model until parse accepts its reply, at most maxAttempts times.
+func askChecked(ctx context.Context, complete completion, parse func(text string) error) error {
+	retry := ""
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var text string
+		text, err = complete(ctx, retry)
+		if err != nil {
+			return err
+		}
+		err = parse(text)
+		if err == nil {
+			return nil
+		}
+		log.Printf("model reply rejected on attempt %d: %v", attempt, err)
+		retry = fmt.Sprintf("Your previous response was rejected: %v. Answer again with valid JSON only, in the format asked above.", err)
+	}
+	return err
+}
+
+// parseCards decodes a reply, repairing it when it was cut off, and keeps the
+// valid cards. A reply with cards but none of them valid is an error.
+func parseCards(text string) (*FlashCardResponse, error) {
+	flashCards, err := convertRespToStruct(trimJSON(text))
+	if err != nil {
+		repaired, ok := repairJSON(text)
+		if !ok {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		flashCards, err = convertRespToStruct(repaired)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		log.Printf("repaired a truncated reply, %d cards kept", len(flashCards.Cards))
+	}
+
+	cards, problems := validateCards(flashCards.Cards)
+	if len(cards) == 0 && len(problems) > 0 {
+		return nil, fmt.Errorf("no valid cards: %s", strings.Join(problems[:min(len(problems), 3)], "; "))
+	}
+	if len(problems) > 0 {
+		log.Printf("dropped %d invalid cards: %s", len(problems), strings.Join(problems, "; "))
+	}
+	return &FlashCardResponse{Cards: cards}, nil
+}
+
+// validateCards returns the usable cards, cleaned up, and why the others were dropped.
+func validateCards(cards []Card) ([]Card, []string) {
+	valid := make([]Card, 0, len(cards))
+	var problems []string
+	for i, card := range cards {
+		card.Front = strings.TrimSpace(card.Front)
+		card.Back = strings.TrimSpace(card.Back)
+		card.Source = strings.TrimSpace(card.Source)
+
+		problem := ""
+		switch {
+		case card.Front == "" || card.Back == "":
+			problem = "empty front or back"
+		case utf8.RuneCountInString(card.Front) > maxFrontLength:
+			problem = fmt.Sprintf("front longer than %d characters", maxFrontLength)
+		case utf8.RuneCountInString(card.Back) > maxBackLength:
+			problem = fmt.Sprintf("back longer than %d characters", maxBackLength)
+		case card.Back == "..." || echoesPrompt(card.Front) || echoesPrompt(card.Back):
+			problem = "copied from the instructions"
+		}
+		if problem != "" {
+			problems = append(problems, fmt.Sprintf("card %d: %s", i+1, problem))
+			continue
+		}
+
+		//page and figure numbers are hints, wrong ones are fixed rather than dropped
+		card.PageStart = max(card.PageStart, 0)
+		card.PageEnd = max(card.PageEnd, card.PageStart)
+		card.Figure = max(card.Figure, 0)
+		valid = append(valid, card)
+	}
+	return valid, problems
+}
+
+func echoesPrompt(text string) bool {
+	text = strings.ToLower(text)
+	for _, echo := range promptEchoes {
+		if strings.Contains(text, echo) {
+			return true
+		}
+	}
+	return false
+}
+
+// repairJSON rescues a reply cut off by the output limit: everything after the
+// last complete card is dropped and the card list closed again.
+func repairJSON(text string) (string, bool) {
+	start := strings.Index(text, "{")
+	if start < 0 {
+		return "", false
+	}
+
+	depth, lastCard := 0, -1
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			//a card is an object closing back into the cards array
+			if c == '}' && depth == 2 {
+				lastCard = i
+			}
+		}
+	}
+	if lastCard < 0 {
+		return "", false
+	}
+	return text[start:lastCard+1] + "]}", true
+}
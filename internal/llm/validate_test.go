@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		ok   bool
+	}{
+		{
+			name: "cut in second card",
+			text: `{"cards": [{"front": "a", "back": "b"}, {"front": "c", "ba`,
+			want: `{"cards": [{"front": "a", "back": "b"}]}`,
+			ok:   true,
+		},
+		{
+			name: "braces inside strings",
+			text: "```json\n" + `{"cards": [{"front": "a}]", "back": "\"{"}, {"fr`,
+			want: `{"cards": [{"front": "a}]", "back": "\"{"}]}`,
+			ok:   true,
+		},
+		{name: "no complete card", text: `{"cards": [{"front": "a"`},
+		{name: "no json", text: "sorry"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := repairJSON(tt.text)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("got %q %v, want %q %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseCards(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		fronts  []string
+		wantErr bool
+	}{
+		{name: "fenced", text: "```json\n{\"cards\": [{\"front\": \" a \", \"back\": \"b\"}]}\n```", fronts: []string{"a"}},
+		{name: "truncated", text: `{"cards": [{"front": "a", "back": "b"}, {"front": "c"`, fronts: []string{"a"}},
+		{name: "invalid dropped", text: `{"cards": [{"front": "", "back": "b"}, {"front": "c", "back": "d"}]}`, fronts: []string{"c"}},
+		{name: "all invalid", text: `{"cards": [{"front": "What is ___?", "back": "..."}]}`, wantErr: true},
+		{name: "no cards", text: `{"cards": []}`},
+		{name: "garbage", text: "no json here", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCards(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var fronts []string
+			for _, c := range got.Cards {
+				fronts = append(fronts, c.Front)
+			}
+			if strings.Join(fronts, "|") != strings.Join(tt.fronts, "|") {
+				t.Errorf("fronts %q, want %q", fronts, tt.fronts)
+			}
+		})
+	}
+}
+
+func TestValidateCards(t *testing.T) {
+	tests := []struct {
+		name  string
+		card  Card
+		valid bool
+	}{
+		{name: "ok", card: Card{Front: "a", Back: "b"}, valid: true},
+		{name: "empty back", card: Card{Front: "a", Back: "  "}},
+		{name: "long front", card: Card{Front: strings.Repeat("x", maxFrontLength+1), Back: "b"}},
+		{name: "long back", card: Card{Front: "a", Back: strings.Repeat("x", maxBackLength+1)}},
+		{name: "long in runes only", card: Card{Front: strings.Repeat("ä", maxFrontLength), Back: strings.Repeat("日", maxBackLength)}, valid: true},
+		{name: "echo", card: Card{Front: "Front field", Back: "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, problems := validateCards([]Card{tt.card})
+			if (len(valid) == 1) != tt.valid || len(valid)+len(problems) != 1 {
+				t.Errorf("valid %v problems %q", valid, problems)
+			}
+		})
+	}
+
+	valid, _ := validateCards([]Card{{Front: "a", Back: "b", PageStart: -2, PageEnd: 0, Figure: -1}})
+	if c := valid[0]; c.PageStart != 0 || c.PageEnd != 0 || c.Figure != 0 {
+		t.Errorf("hints not fixed: %+v", c)
+	}
+	valid, _ = validateCards([]Card{{Front: "a", Back: "b", PageStart: 5, PageEnd: 3}})
+	if valid[0].PageEnd != 5 {
+		t.Errorf("page end %d, want 5", valid[0].PageEnd)
+	}
+}
+
+func TestAskChecked(t *testing.T) {
+	failed := errors.New("request failed")
+	tests := []struct {
+		name    string
+		replies []string
+		err     error
+		calls   int
+		wantErr bool
+	}{
+		{name: "first try", replies: []string{"ok"}, calls: 1},
+		{name: "retried", replies: []string{"bad", "ok"}, calls: 2},
+		{name: "gives up", replies: []string{"bad", "bad", "ok"}, calls: maxAttempts, wantErr: true},
+		{name: "request error", replies: []string{"ok"}, err: failed, calls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var retries []string
+			complete := func(ctx context.Context, retry string) (string, error) {
+				retries = append(retries, retry)
+				if tt.err != nil {
+					return "", tt.err
+				}
+				return tt.replies[len(retries)-1], nil
+			}
+			err := askChecked(context.Background(), complete, func(text string) error {
+				if text != "ok" {
+					return errors.New("not ok")
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+			if len(retries) != tt.calls {
+				t.Fatalf("%d calls, want %d", len(retries), tt.calls)
+			}
+			if retries[0] != "" {
+				t.Errorf("first call had retry %q", retries[0])
+			}
+			for _, retry := range retries[1:] {
+				if !strings.Contains(retry, "not ok") {
+					t.Errorf("retry %q doesn't say what was wrong", retry)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAIRetryShowsRejectedReply(t *testing.T) {
+	replies := []string{`{"cards": [{"front": "", "back": ""}]}`, `{"cards": [{"front": "a", "back": "b"}]}`}
+	var requests []chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, req)
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": replies[len(requests)-1]}}},
+		})
+	}))
+	defer server.Close()
+
+	s, err := NewOpenAI(server.URL, "key", "model", "embedding")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.generate(context.Background(), "material")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Cards) != 1 || len(requests) != 2 {
+		t.Fatalf("%d cards after %d requests", len(resp.Cards), len(requests))
+	}
+	messages := requests[1].Messages
+	if len(messages) != 3 || messages[1].Role != "assistant" || messages[1].Content != replies[0] || messages[2].Role != "user" {
+		t.Errorf("retry sent %+v", messages)
+	}
+}
@@ -0,0 +1,214 @@
+package printout
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// DejaVu Sans covers Latin, Greek, Cyrillic and most symbols, unlike the core
+// PDF fonts. It is free to embed, see dejavu-fonts.github.io for the license.
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	fontBold []byte
+)
+
+const fontFamily = "DejaVu"
+
+const (
+	// fronts on one page and backs on the next, mirrored so a duplex print lines them up
+	LayoutCards = "cards"
+	// question and answer side by side, many cards per page
+	LayoutSheet = "sheet"
+)
+
+// A4 portrait in mm
+const (
+	pageWidth  = 210.0
+	pageHeight = 297.0
+	margin     = 10.0
+)
+
+// flashcard grid of the cards layout
+const (
+	gridColumns = 2
+	gridRows    = 4
+	cellPadding = 4.0
+)
+
+// font sizes in pt, card text shrinks down to the minimum before it is cut
+const (
+	maxCardFont = 14.0
+	minCardFont = 7.0
+	sheetFont   = 10.0
+)
+
+type Card struct {
+	Front string
+	Back  string
+}
+
+func ValidLayout(layout string) bool {
+	return layout == LayoutCards || layout == LayoutSheet
+}
+
+// Write renders cards as a printable A4 PDF with an embedded Unicode font.
+// Characters beyond the Basic Multilingual Plane, emoji mostly, print as
+// question marks.
+func Write(w io.Writer, title string, cards []Card, layout string) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, margin)
+	pdf.SetTitle(title, true)
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBold)
+
+	switch layout {
+	case LayoutCards:
+		writeCards(pdf, cards)
+	case LayoutSheet:
+		writeSheet(pdf, title, cards)
+	default:
+		return fmt.Errorf("unknown layout %q", layout)
+	}
+
+	if pdf.PageCount() == 0 {
+		pdf.AddPage()
+	}
+	return pdf.Output(w)
+}
+
+func writeCards(pdf *gofpdf.Fpdf, cards []Card) {
+	cellWidth := (pageWidth - 2*margin) / gridColumns
+	cellHeight := (pageHeight - 2*margin) / gridRows
+	perPage := gridColumns * gridRows
+
+	for start := 0; start < len(cards); start += perPage {
+		page := cards[start:min(start+perPage, len(cards))]
+		for side := 0; side < 2; side++ {
+			pdf.AddPage()
+			for i, card := range page {
+				col, row := i%gridColumns, i/gridColumns
+				text := card.Front
+				if side == 1 {
+					//the back of a sheet flips left and right
+					col = gridColumns - 1 - col
+					text = card.Back
+				}
+				x := margin + float64(col)*cellWidth
+				y := margin + float64(row)*cellHeight
+
+				pdf.SetDrawColor(180, 180, 180)
+				pdf.SetDashPattern([]float64{2, 2}, 0)
+				pdf.Rect(x, y, cellWidth, cellHeight, "D")
+				pdf.SetDashPattern([]float64{}, 0)
+				fitText(pdf, printable(text), x+cellPadding, y+cellPadding, cellWidth-2*cellPadding, cellHeight-2*cellPadding)
+			}
+		}
+	}
+}
+
+// fitText writes text centered in the box, at the biggest font size it fits.
+func fitText(pdf *gofpdf.Fpdf, text string, x, y, width, height float64) {
+	size := maxCardFont
+	var lines []string
+	for {
+		pdf.SetFont(fontFamily, "", size)
+		lines = pdf.SplitText(text, width)
+		if float64(len(lines))*lineHeight(size) <= height || size <= minCardFont {
+			break
+		}
+		size--
+	}
+	lh := lineHeight(size)
+	if fit := int(height / lh); len(lines) > fit {
+		lines = lines[:fit]
+		lines[fit-1] = strings.TrimRight(lines[fit-1], " ") + "…"
+	}
+
+	top := y + (height-float64(len(lines))*lh)/2
+	for i, line := range lines {
+		pdf.SetXY(x, top+float64(i)*lh)
+		pdf.CellFormat(width, lh, line, "", 0, "C", false, 0, "")
+	}
+}
+
+func writeSheet(pdf *gofpdf.Fpdf, title string, cards []Card) {
+	numberWidth := 10.0
+	questionWidth := (pageWidth - 2*margin - numberWidth) * 0.4
+	answerWidth := pageWidth - 2*margin - numberWidth - questionWidth
+	lh := lineHeight(sheetFont)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(pageHeight - margin)
+		pdf.SetFont(fontFamily, "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d", pdf.PageNo()), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+
+	pdf.AddPage()
+	pdf.SetFont(fontFamily, "B", 16)
+	pdf.CellFormat(0, 10, printable(title), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	bottom := pageHeight - 2*margin
+	for i, card := range cards {
+		pdf.SetFont(fontFamily, "B", sheetFont)
+		question := pdf.SplitText(printable(card.Front), questionWidth-2)
+		pdf.SetFont(fontFamily, "", sheetFont)
+		answer := pdf.SplitText(printable(card.Back), answerWidth-2)
+		height := float64(max(len(question), len(answer)))*lh + 2
+
+		if pdf.GetY()+height > bottom {
+			pdf.AddPage()
+		}
+		y := pdf.GetY()
+
+		pdf.SetDrawColor(200, 200, 200)
+		pdf.Line(margin, y, pageWidth-margin, y)
+
+		pdf.SetXY(margin, y+1)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(numberWidth, lh, fmt.Sprintf("%d.", i+1), "", 0, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+
+		pdf.SetFont(fontFamily, "B", sheetFont)
+		writeLines(pdf, question, margin+numberWidth, y+1, questionWidth, lh)
+		pdf.SetFont(fontFamily, "", sheetFont)
+		writeLines(pdf, answer, margin+numberWidth+questionWidth, y+1, answerWidth, lh)
+
+		pdf.SetY(y + height)
+	}
+}
+
+// writeLines prints lines split by SplitText, long cards run past the page
+// end only when a single card is taller than a page.
+func writeLines(pdf *gofpdf.Fpdf, lines []string, x, y, width, lh float64) {
+	for i, line := range lines {
+		pdf.SetXY(x, y+float64(i)*lh)
+		pdf.CellFormat(width, lh, line, "", 0, "L", false, 0, "")
+	}
+}
+
+// printable swaps characters the font tables can't hold for question marks,
+// gofpdf only measures the Basic Multilingual Plane.
+func printable(text string) string {
+	text = strings.ReplaceAll(text, "\r", "")
+	return strings.Map(func(r rune) rune {
+		if r > 0xFFFF {
+			return '?'
+		}
+		return r
+	}, text)
+}
+
+// lineHeight converts a font size in pt to a line height in mm.
+func lineHeight(size float64) float64 {
+	return size * 0.3528 * 1.3
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// CardAssist returns a hint or an explanation of a card of the collection. They
+// are written once per content of the card, editing it writes them again.
+func (s *Server) CardAssist(ctx context.Context, userID, collectionID, cardID uuid.UUID, kind string) (*CardAssist, error) {
+	dbCard, err := s.collectionCard(ctx, userID, collectionID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	revision := cardRevision(dbCard)
+
+	cached, err := s.dB.GetCardAssist(ctx, database.GetCardAssistParams{CardID: cardID, Kind: kind, Revision: revision})
+	if err == nil {
+		return &CardAssist{Kind: kind, Text: cached.Body, Cached: true, CreatedAt: cached.CreatedAt}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("error on getting card %s: %v", kind, err)
+	}
+
+	req := llm.AssistRequest{Kind: kind, Front: dbCard.Front, Back: dbCard.Back, Source: dbCard.SourceExcerpt.String}
+	if kind == llm.AssistExplain {
+		req.Material = s.sourcePages(ctx, dbCard)
+	}
+	text, err := s.generator.Assist(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("error on writing card %s: %v", kind, err)
+	}
+	saved, err := s.dB.SaveCardAssist(ctx, database.SaveCardAssistParams{CardID: cardID, Kind: kind, Revision: revision, Body: text})
+	if err != nil {
+		return nil, fmt.Errorf("error on saving card %s: %v", kind, err)
+	}
+	return &CardAssist{Kind: kind, Text: saved.Body, CreatedAt: saved.CreatedAt}, nil
+}
+
+// cardRevision identifies what a hint was written for, it changes with the card.
+func cardRevision(card database.Card) string {
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s\x00%s\x00%s\x00%d-%d", card.Front, card.Back, card.SourceExcerpt.String, card.SourcePageStart.Int32, card.SourcePageEnd.Int32)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// sourcePages is the text of the pages a card was generated from, as the
+// worker stored it for the chat, or all of the text it was generated from when
+// that was pasted. It is empty for other cards, and only context for the model:
+// failing to get it isn't an error.
+func (s *Server) sourcePages(ctx context.Context, card database.Card) string {
+	if !card.FileID.Valid {
+		return ""
+	}
+	if first := card.SourcePageStart.Int32; first > 0 {
+		passages, err := s.dB.ListFileChunkPages(ctx, database.ListFileChunkPagesParams{
+			FileID:    card.FileID.UUID,
+			FirstPage: first,
+			LastPage:  max(card.SourcePageEnd.Int32, first),
+		})
+		if err != nil {
+			log.Printf("cannot get source pages of card %v: %v", card.ID, err)
+		}
+		return strings.Join(passages, "\n\n")
+	}
+
+	format, err := s.dB.GetFileFormat(ctx, card.FileID.UUID)
+	if err != nil || (format.String != "text" && format.String != "markdown") {
+		return ""
+	}
+	text, err := s.dB.GetFileText(ctx, card.FileID.UUID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("cannot get source text of card %v: %v", card.ID, err)
+	}
+	return text
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrInvalidChat = errors.New("invalid chat request")
+
+var ErrNoFileText = errors.New("no file text to answer from")
+
+const (
+	// passages sent with a question
+	maxChatPassages = 6
+	maxChatQuestion = 2000
+	// earlier turns sent along, the oldest are dropped
+	maxChatHistory = 10
+	maxChatMessage = 4000
+	// the part of a passage shown with a citation
+	maxCitationExcerpt = 300
+	// without pgvector only the passages of the newest files are compared,
+	// like maxInProcessEmbeddings for cards
+	maxInProcessChunks = 5000
+)
+
+// kept out of sqlc on purpose, like nearestCardsPgvector
+const nearestChunksPgvector = `
+SELECT file_chunks.id, file_chunks.file_id, files.file_name, file_chunks.page, file_chunks.body
+FROM file_chunks
+JOIN files ON files.id = file_chunks.file_id
+WHERE files.collection_id = $1 AND file_chunks.model = $2 AND files.deleted_at IS NULL
+  AND ($4::uuid IS NULL OR file_chunks.file_id = $4::uuid)
+ORDER BY file_chunks.embedding::vector <=> $3::real[]::vector
+LIMIT $5
+`
+
+type fileChunk struct {
+	id       uuid.UUID
+	fileID   uuid.UUID
+	fileName string
+	page     int
+	body     string
+}
+
+// Chat answers a question from the text of the collection's processed files.
+// Citations lists the passages the answer cites, by the number it uses.
+func (s *Server) Chat(ctx context.Context, collectionID uuid.UUID, q ChatQuestion) (*ChatAnswer, error) {
+	q.Question = strings.TrimSpace(q.Question)
+	if q.Question == "" || utf8.RuneCountInString(q.Question) > maxChatQuestion {
+		return nil, fmt.Errorf("%w: question must be between 1 and %d characters", ErrInvalidChat, maxChatQuestion)
+	}
+	for _, m := range q.History {
+		if m.Role != llm.ChatUser && m.Role != llm.ChatAssistant {
+			return nil, fmt.Errorf("%w: history roles must be user or assistant", ErrInvalidChat)
+		}
+		if utf8.RuneCountInString(m.Content) > maxChatMessage {
+			return nil, fmt.Errorf("%w: history messages must be at most %d characters", ErrInvalidChat, maxChatMessage)
+		}
+	}
+	if len(q.History) > maxChatHistory {
+		q.History = q.History[len(q.History)-maxChatHistory:]
+	}
+
+	vectors, err := s.embedder.Embed(ctx, []string{q.Question})
+	if err != nil {
+		return nil, fmt.Errorf("error on embedding question: %v", err)
+	}
+	fileID := uuid.NullUUID{}
+	if q.FileID != nil {
+		fileID = uuid.NullUUID{UUID: *q.FileID, Valid: true}
+	}
+	chunks, err := s.nearestChunks(ctx, collectionID, fileID, vectors[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 0 {
+		return nil, ErrNoFileText
+	}
+
+	passages := make([]llm.Passage, len(chunks))
+	for i, c := range chunks {
+		source := c.fileName
+		if c.page > 0 {
+			source = fmt.Sprintf("%s, page %d", c.fileName, c.page)
+		}
+		passages[i] = llm.Passage{Number: i + 1, Source: source, Text: c.body}
+	}
+	answer, err := s.generator.Chat(ctx, llm.ChatRequest{Question: q.Question, History: q.History, Passages: passages})
+	if err != nil {
+		return nil, fmt.Errorf("error on answering question: %v", err)
+	}
+
+	citations := []ChatCitation{}
+	for _, n := range llm.Citations(answer) {
+		if n < 1 || n > len(chunks) {
+			continue
+		}
+		c := chunks[n-1]
+		citations = append(citations, ChatCitation{
+			Number:   n,
+			ChunkID:  c.id,
+			FileID:   c.fileID,
+			FileName: c.fileName,
+			Page:     c.page,
+			Excerpt:  citationExcerpt(c.body),
+		})
+	}
+	return &ChatAnswer{Answer: answer, AnswerHTML: content.RenderHTML(answer, content.FormatMarkdown), Citations: citations}, nil
+}
+
+// ChatToCard saves an answer as a card of the collection, with the question on
+// the front. The cited passage, if given, becomes the source of the card.
+func (s *Server) ChatToCard(ctx context.Context, collectionID uuid.UUID, c ChatCard) (*Card, error) {
+	front, back := strings.TrimSpace(c.Question), llm.StripCitations(c.Answer)
+	if front == "" || back == "" {
+		return nil, fmt.Errorf("%w: question and answer are needed", ErrInvalidChat)
+	}
+
+	var chunk database.GetFileChunkRow
+	if c.ChunkID != nil {
+		var err error
+		chunk, err = s.dB.GetFileChunk(ctx, database.GetFileChunkParams{ID: *c.ChunkID, CollectionID: collectionID})
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: no such passage in the collection", ErrInvalidChat)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error on getting passage: %v", err)
+		}
+	}
+
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := s.dB.WithTx(tx)
+
+	card := Card{Front: front, Back: back, Format: content.FormatMarkdown}
+	err = s.createCard(ctx, qtx, collectionID, &card)
+	if err != nil {
+		return nil, err
+	}
+	if c.ChunkID != nil {
+		page := sql.NullInt32{Int32: chunk.Page, Valid: chunk.Page > 0}
+		excerpt := citationExcerpt(chunk.Body)
+		err = qtx.SetCardSource(ctx, database.SetCardSourceParams{
+			ID:              card.ID,
+			FileID:          uuid.NullUUID{UUID: chunk.FileID, Valid: true},
+			SourcePageStart: page,
+			SourcePageEnd:   page,
+			SourceExcerpt:   sql.NullString{String: excerpt, Valid: true},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error on setting card source: %v", err)
+		}
+		card.Source = &CardSource{FileID: chunk.FileID, FileName: chunk.FileName.String, PageStart: int(chunk.Page), PageEnd: int(chunk.Page), Excerpt: excerpt}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	card.renderHTML(nil)
+	return &card, nil
+}
+
+// nearestChunks returns the passages closest to vector, of one file when fileID is set.
+func (s *Server) nearestChunks(ctx context.Context, collectionID uuid.UUID, fileID uuid.NullUUID, vector []float32) ([]fileChunk, error) {
+	if s.pgvector {
+		return s.nearestChunksPgvector(ctx, collectionID, fileID, vector)
+	}
+
+	rows, err := s.dB.ListFileChunkEmbeddings(ctx, database.ListFileChunkEmbeddingsParams{CollectionID: collectionID, Model: s.embedder.EmbeddingModel(), FileID: fileID, MaxChunks: maxInProcessChunks})
+	if err != nil {
+		return nil, fmt.Errorf("error on listing file passages: %v", err)
+	}
+	similarity := make([]float64, len(rows))
+	order := make([]int, len(rows))
+	for i := range rows {
+		similarity[i] = llm.CosineSimilarity(vector, rows[i].Embedding)
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return similarity[order[a]] > similarity[order[b]]
+	})
+
+	chunks := make([]fileChunk, 0, min(len(rows), maxChatPassages))
+	for _, i := range order[:min(len(order), maxChatPassages)] {
+		chunks = append(chunks, fileChunk{id: rows[i].ID, fileID: rows[i].FileID, fileName: rows[i].FileName.String, page: int(rows[i].Page), body: rows[i].Body})
+	}
+	return chunks, nil
+}
+
+func (s *Server) nearestChunksPgvector(ctx context.Context, collectionID uuid.UUID, fileID uuid.NullUUID, vector []float32) ([]fileChunk, error) {
+	rows, err := s.rawDB.QueryContext(ctx, nearestChunksPgvector, collectionID, s.embedder.EmbeddingModel(), pq.Array(vector), fileID, maxChatPassages)
+	if err != nil {
+		return nil, fmt.Errorf("error on querying nearest passages: %v", err)
+	}
+	defer rows.Close()
+
+	var chunks []fileChunk
+	for rows.Next() {
+		var c fileChunk
+		var fileName sql.NullString
+		err = rows.Scan(&c.id, &c.fileID, &fileName, &c.page, &c.body)
+		if err != nil {
+			return nil, fmt.Errorf("error on scanning nearest passages: %v", err)
+		}
+		c.fileName = fileName.String
+		chunks = append(chunks, c)
+	}
+	return chunks, rows.Err()
+}
+
+func citationExcerpt(body string) string {
+	body = strings.Join(strings.Fields(body), " ")
+	if runes := []rune(body); len(runes) > maxCitationExcerpt {
+		body = string(runes[:maxCitationExcerpt]) + "…"
+	}
+	return body
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CardStatusDraft    = "draft"
+	CardStatusActive   = "active"
+	CardStatusRejected = "rejected"
+)
+
+var (
+	ErrFileNotFound = errors.New("no such file")
+	ErrNotADraft    = errors.New("card is not a draft of this file")
+)
+
+// collectionFileName returns the name of fileID, or ErrFileNotFound when the file isn't in collectionID.
+func (s *Server) collectionFileName(ctx context.Context, collectionID, fileID uuid.UUID) (sql.NullString, error) {
+	fileName, err := s.dB.GetCollectionFileName(ctx, database.GetCollectionFileNameParams{ID: fileID, CollectionID: collectionID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fileName, ErrFileNotFound
+	}
+	if err != nil {
+		return fileName, fmt.Errorf("error on getting file: %v", err)
+	}
+	return fileName, nil
+}
+
+func (s *Server) ListDrafts(ctx context.Context, userID, collectionID, fileID uuid.UUID) ([]Card, error) {
+	fileName, err := s.collectionFileName(ctx, collectionID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	dbCards, err := s.dB.ListDraftCards(ctx, database.ListDraftCardsParams{FileID: uuid.NullUUID{UUID: fileID, Valid: true}, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("error on listing drafts: %v", err)
+	}
+
+	merges, err := s.dB.ListMergeDrafts(ctx, uuid.NullUUID{UUID: fileID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("error on listing merge drafts: %v", err)
+	}
+	isMerge := make(map[uuid.UUID]bool, len(merges))
+	for _, id := range merges {
+		isMerge[id] = true
+	}
+
+	cards := make([]Card, len(dbCards))
+	for i := range dbCards {
+		cards[i] = cardFromDB(dbCards[i])
+		cards[i].Source.FileName = fileName.String
+		cards[i].Merge = isMerge[cards[i].ID]
+	}
+	//drafts can show figures extracted from the file
+	if len(dbCards) > 0 {
+		err = s.attachMedia(ctx, dbCards[0].CollectionID, cards)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cards, nil
+}
+
+// ReviewDrafts moves drafts of a file to status (active or rejected). With no cardIDs
+// every remaining draft of the file is moved. Accepted merge drafts replace the back of
+// the card they duplicate and are removed. It returns the number of drafts changed and
+// the IDs of the cards that got a merged back.
+func (s *Server) ReviewDrafts(ctx context.Context, userID, collectionID, fileID uuid.UUID, cardIDs []uuid.UUID, status string) (int64, []uuid.UUID, error) {
+	_, err := s.collectionFileName(ctx, collectionID, fileID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+	qtx := s.dB.WithTx(tx)
+
+	file := uuid.NullUUID{UUID: fileID, Valid: true}
+	var n int64
+	if len(cardIDs) == 0 {
+		n, err = qtx.SetAllDraftsStatus(ctx, database.SetAllDraftsStatusParams{Status: status, FileID: file, UserID: userID})
+	} else {
+		n, err = qtx.SetDraftsStatus(ctx, database.SetDraftsStatusParams{Status: status, FileID: file, UserID: userID, CardIds: cardIDs})
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("error on updating drafts: %v", err)
+	}
+
+	var merged []uuid.UUID
+	if status == CardStatusActive {
+		merged, err = qtx.ApplyMergeDrafts(ctx, file)
+		if err != nil {
+			return 0, nil, fmt.Errorf("error on applying merge drafts: %v", err)
+		}
+	}
+	return n, merged, tx.Commit()
+}
+
+// AcceptEditedDraft saves the user's edit of a draft and accepts it in one step.
+// A merge draft gives its edited back to the card it duplicates, card then describes that card.
+func (s *Server) AcceptEditedDraft(ctx context.Context, userID, collectionID, fileID uuid.UUID, card *Card) error {
+	_, err := s.collectionFileName(ctx, collectionID, fileID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.dB.WithTx(tx)
+
+	//status update goes first, it checks the card is a draft the user owns
+	n, err := qtx.SetDraftsStatus(ctx, database.SetDraftsStatusParams{
+		Status:  CardStatusActive,
+		FileID:  uuid.NullUUID{UUID: fileID, Valid: true},
+		UserID:  userID,
+		CardIds: []uuid.UUID{card.ID},
+	})
+	if err != nil {
+		return fmt.Errorf("error on accepting draft: %v", err)
+	}
+	if n == 0 {
+		return ErrNotADraft
+	}
+
+	//tags left out of the edit stay as they are
+	var tags []string
+	if card.Tags != nil {
+		card.Tags = content.NormalizeTags(card.Tags)
+		tags = card.Tags
+	}
+	//drafts are generated as markdown
+	if card.Format == "" {
+		card.Format = content.FormatMarkdown
+	}
+	_, err = qtx.UpdateCard(ctx, database.UpdateCardParams{
+		ID:            card.ID,
+		CollectionID:  collectionID,
+		Front:         card.Front,
+		Back:          card.Back,
+		Tags:          tags,
+		ContentFormat: card.Format,
+	})
+	if err != nil {
+		return fmt.Errorf("error on updating draft: %v", err)
+	}
+	merged, err := qtx.ApplyMergeDrafts(ctx, uuid.NullUUID{UUID: fileID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("error on applying merge draft: %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	if len(merged) > 0 {
+		card.ID = merged[0]
+		card.DuplicateOf = nil
+		card.Merge = false
+	}
+
+	card.Status = CardStatusActive
+	card.renderHTML(nil)
+	return nil
+}
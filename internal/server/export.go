@@ -0,0 +1,235 @@
+package server
+
+import (
+	"CueMind/internal/anki"
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"CueMind/internal/printout"
+	"CueMind/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExportAnki = "apkg"
+	ExportCSV  = "csv"
+	ExportTSV  = "tsv"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+var ErrUnknownLayout = errors.New("unknown printout layout")
+
+// Export is a finished export spooled to a temp file. Close removes it.
+type Export struct {
+	File        *os.File
+	FileName    string
+	ContentType string
+}
+
+func (e *Export) Close() error {
+	e.File.Close()
+	return os.Remove(e.File.Name())
+}
+
+// ExportCollection writes the active cards of a collection in the given format.
+func (s *Server) ExportCollection(ctx context.Context, userID, collectionID uuid.UUID, format string) (*Export, error) {
+	if format != ExportAnki {
+		return nil, ErrUnknownExportFormat
+	}
+
+	dbCollection, err := s.dB.GetCollectionById(ctx, database.GetCollectionByIdParams{ID: collectionID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("error on gettig collection: %v", err)
+	}
+
+	tmp, err := os.CreateTemp("", "cuemind-export-*")
+	if err != nil {
+		return nil, err
+	}
+	export := &Export{File: tmp, FileName: dbCollection.Name + ".apkg", ContentType: "application/apkg"}
+
+	err = s.exportAnki(ctx, tmp, collectionID, dbCollection.Name)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		export.Close()
+		return nil, err
+	}
+	return export, nil
+}
+
+func (s *Server) exportAnki(ctx context.Context, w io.Writer, collectionID uuid.UUID, name string) error {
+	dbCards, err := s.dB.ListCardsForExport(ctx, collectionID)
+	if err != nil {
+		return fmt.Errorf("error on getting cards: %v", err)
+	}
+	dbReviews, err := s.dB.ListCollectionReviews(ctx, collectionID)
+	if err != nil {
+		return fmt.Errorf("error on getting reviews: %v", err)
+	}
+	dbMedia, err := s.dB.ListCollectionMedia(ctx, collectionID)
+	if err != nil {
+		return fmt.Errorf("error on listing media: %v", err)
+	}
+
+	//media is packed under its id, card text references it by file name
+	deck := anki.ExportDeck{Name: name}
+	fileNames := make(map[string]string, len(dbMedia))
+	sounds := make(map[uuid.UUID][]string)
+	for _, m := range dbMedia {
+		fileName := m.ID.String() + content.MediaExtension(m.ContentType)
+		fileNames[m.ID.String()] = fileName
+		if m.Kind == "audio" && m.CardID.Valid {
+			sounds[m.CardID.UUID] = append(sounds[m.CardID.UUID], fileName)
+		}
+		key := storage.MediaKey(m.ID.String())
+		deck.Media = append(deck.Media, anki.ExportMedia{
+			Name: fileName,
+			Open: func() (io.ReadCloser, error) { return s.storage.GetFile(ctx, key) },
+		})
+	}
+
+	reviews := make(map[uuid.UUID][]anki.Review)
+	for _, r := range dbReviews {
+		last := 0
+		if prev := reviews[r.CardID]; len(prev) > 0 {
+			last = prev[len(prev)-1].Interval
+		}
+		//revlog type 0 is a learning step, 1 a review
+		reviewType := 1
+		if last == 0 {
+			reviewType = 0
+		}
+		reviews[r.CardID] = append(reviews[r.CardID], anki.Review{
+			ID:           r.ReviewedAt.UnixMilli(),
+			Ease:         int(r.Rating),
+			Interval:     int(r.IntervalDays),
+			LastInterval: last,
+			Factor:       int(r.EaseFactor),
+			Time:         int(r.DurationMs),
+			Type:         reviewType,
+		})
+	}
+
+	for _, c := range dbCards {
+		front, back := c.Front, c.Back
+		if c.ContentFormat == content.FormatMarkdown {
+			front = content.ResolveMedia(front, fileNames)
+			back = content.ResolveMedia(back, fileNames)
+		}
+		front = content.RenderMathJax(front, c.ContentFormat)
+		back = content.RenderMathJax(back, c.ContentFormat)
+		for _, sound := range sounds[c.ID] {
+			back += "[sound:" + sound + "]"
+		}
+
+		note := anki.ExportNote{
+			GUID:    c.ID.String(),
+			Front:   front,
+			Back:    back,
+			Tags:    c.Tags,
+			Type:    anki.CardNew,
+			Reviews: reviews[c.ID],
+		}
+		if c.State.Valid {
+			note.Type = exportCardType(c.State.String)
+			note.Interval = int(c.IntervalDays.Int32)
+			note.Factor = int(c.EaseFactor.Int32)
+			note.Reps = int(c.Reps.Int32)
+			note.Lapses = int(c.Lapses.Int32)
+			if c.DueDate.Valid {
+				note.Due = c.DueDate.Time
+			}
+		}
+		deck.Notes = append(deck.Notes, note)
+	}
+
+	err = anki.Write(w, deck)
+	if err != nil {
+		return fmt.Errorf("error on writing anki package: %v", err)
+	}
+	return nil
+}
+
+// exportCardType maps card_schedules.state to the anki card type.
+func exportCardType(state string) int {
+	switch state {
+	case "learning":
+		return anki.CardLearning
+	case "review":
+		return anki.CardReview
+	case "relearning":
+		return anki.CardRelearning
+	}
+	return anki.CardNew
+}
+
+// printouts are deleted a day after they were requested
+const pdfExportLifetime = 24 * time.Hour
+
+// CreatePdfExport registers a printout for the worker to render.
+func (s *Server) CreatePdfExport(ctx context.Context, userID, collectionID uuid.UUID, layout string) (*PdfExport, error) {
+	if !printout.ValidLayout(layout) {
+		return nil, ErrUnknownLayout
+	}
+	dbCollection, err := s.dB.GetCollectionById(ctx, database.GetCollectionByIdParams{ID: collectionID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("error on gettig collection: %v", err)
+	}
+	id, err := s.dB.CreatePdfExport(ctx, database.CreatePdfExportParams{CollectionID: collectionID, UserID: userID, Layout: layout})
+	if err != nil {
+		return nil, fmt.Errorf("error on creating export: %v", err)
+	}
+	return &PdfExport{ID: id, CollectionName: dbCollection.Name, Layout: layout, Status: "pending", CreatedAt: time.Now()}, nil
+}
+
+func (s *Server) GetPdfExport(ctx context.Context, userID, collectionID, exportID uuid.UUID) (*PdfExport, error) {
+	dbExport, err := s.dB.GetPdfExport(ctx, database.GetPdfExportParams{ID: exportID, CollectionID: collectionID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("error on getting export: %v", err)
+	}
+	export := &PdfExport{ID: dbExport.ID, Layout: dbExport.Layout, Status: dbExport.Status, CreatedAt: dbExport.CreatedAt}
+	if export.Status == "ready" {
+		export.URL, err = s.storage.GeneratePresignedGetUrl(ctx, storage.ExportKey(exportID.String()), mediaUrlLifetime)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return export, nil
+}
+
+// PurgePdfExports deletes printouts requested longer than maxAge ago, the PDF
+// and the row. Their links have long expired by then.
+func (s *Server) PurgePdfExports(ctx context.Context, maxAge time.Duration) error {
+	ids, err := s.dB.ListExpiredPdfExports(ctx, time.Now().Add(-maxAge))
+	if err != nil {
+		return fmt.Errorf("error on listing expired exports: %v", err)
+	}
+	for _, id := range ids {
+		err = s.storage.DeleteFile(ctx, storage.ExportKey(id.String()))
+		if err != nil {
+			//the row stays for the next run
+			log.Printf("cannot delete export %v from storage: %v", id, err)
+			continue
+		}
+		err = s.dB.DeletePdfExport(ctx, id)
+		if err != nil {
+			return fmt.Errorf("error on deleting export: %v", err)
+		}
+	}
+	return nil
+}
+
+// FailPdfExport marks an export that could not be queued as failed.
+func (s *Server) FailPdfExport(ctx context.Context, exportID uuid.UUID) error {
+	return s.dB.SetPdfExportStatus(ctx, database.SetPdfExportStatusParams{Status: "failed", ID: exportID})
+}
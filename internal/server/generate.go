@@ -0,0 +1,80 @@
+package server
+
+import (
+	"CueMind/internal/database"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// pasted text goes to the model in one request, like one chunk of a long file
+const maxGenerateText = 24000
+
+const maxTopicLength = 500
+
+// files made for pasted text are named after its start
+const sourceNameLength = 60
+
+var ErrInvalidGeneration = errors.New("invalid generation request")
+
+// CreateTextSource checks a text generation request and makes the file entry its
+// cards are drafted under, so they show up in the inbox like the cards of an
+// upload. Pasted text is stored with the entry.
+func (s *Server) CreateTextSource(ctx context.Context, userID, collectionID uuid.UUID, req TextGeneration) (*File, error) {
+	text, topic := strings.TrimSpace(req.Text), strings.TrimSpace(req.Topic)
+	if (text == "") == (topic == "") {
+		return nil, fmt.Errorf("%w: send either text or a topic", ErrInvalidGeneration)
+	}
+	if utf8.RuneCountInString(text) > maxGenerateText {
+		return nil, fmt.Errorf("%w: text is longer than %d characters, upload it as a file", ErrInvalidGeneration, maxGenerateText)
+	}
+	if utf8.RuneCountInString(topic) > maxTopicLength {
+		return nil, fmt.Errorf("%w: topic is longer than %d characters", ErrInvalidGeneration, maxTopicLength)
+	}
+
+	file := File{UserID: userID, CollectionID: collectionID}
+	switch {
+	case topic != "":
+		file.Format = "topic"
+		file.Filename = sourceName(topic)
+	case req.Format == "" || req.Format == "text":
+		file.Format = "text"
+		file.Filename = sourceName(text)
+	case req.Format == "markdown":
+		file.Format = "markdown"
+		file.Filename = sourceName(text)
+	default:
+		return nil, fmt.Errorf("%w: format must be text or markdown", ErrInvalidGeneration)
+	}
+
+	err := s.CreateFileEntry(ctx, &file)
+	if err != nil {
+		return nil, err
+	}
+	err = s.CompeleteFileDetails(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	//kept like an upload, the worker and explanations of the cards read it back
+	if text != "" {
+		err = s.dB.CreateFileText(ctx, database.CreateFileTextParams{FileID: file.ID, Body: text})
+		if err != nil {
+			return nil, fmt.Errorf("error on saving text: %v", err)
+		}
+	}
+	return &file, nil
+}
+
+// sourceName is the first line of text, cut to fit a file list.
+func sourceName(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	line = strings.TrimSpace(strings.TrimLeft(line, "# "))
+	if runes := []rune(line); len(runes) > sourceNameLength {
+		line = string(runes[:sourceNameLength]) + "…"
+	}
+	return line
+}
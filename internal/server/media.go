@@ -0,0 +1,192 @@
+package server
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"CueMind/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// minutes a media download link stays valid
+const mediaUrlLifetime = 60
+
+// largest image or audio file a card can have
+const maxMediaSize = 20 << 20
+
+var (
+	ErrUnsupportedMedia = errors.New("unsupported media type")
+	ErrInvalidMediaSize = errors.New("media size must be between 1 byte and 20MB")
+	ErrMediaNotFound    = errors.New("media not found in collection")
+	ErrInvalidUpload    = errors.New("uploaded file doesn't match the registered media")
+)
+
+// CreateMediaUpload registers a media file and returns it with a presigned URL to upload it to.
+// The URL only accepts an upload of contentType that is exactly size bytes.
+// The media only shows up on cards once VerifyMediaUpload confirms the upload.
+func (s *Server) CreateMediaUpload(ctx context.Context, userID, collectionID uuid.UUID, cardID uuid.NullUUID, contentType string, size int64) (*Media, string, error) {
+	kind, ok := content.MediaKind(contentType)
+	if !ok {
+		return nil, "", ErrUnsupportedMedia
+	}
+	if size <= 0 || size > maxMediaSize {
+		return nil, "", ErrInvalidMediaSize
+	}
+
+	if cardID.Valid {
+		card, err := s.dB.GetCard(ctx, database.GetCardParams{ID: cardID.UUID, UserID: userID})
+		if err != nil || card.CollectionID != collectionID {
+			return nil, "", fmt.Errorf("card %v not found in collection", cardID.UUID)
+		}
+	}
+
+	id, err := s.dB.CreateMedia(ctx, database.CreateMediaParams{CollectionID: collectionID, CardID: cardID, ContentType: contentType, Kind: kind})
+	if err != nil {
+		return nil, "", fmt.Errorf("error on creating media: %v", err)
+	}
+
+	uploadURL, err := s.storage.GeneratePresignedPutUrl(ctx, storage.MediaKey(id.String()), contentType, size, 5)
+	if err != nil {
+		return nil, "", err
+	}
+
+	media := &Media{ID: id, Kind: kind, ContentType: contentType, Reference: content.MediaScheme + id.String()}
+	if cardID.Valid {
+		media.CardID = &cardID.UUID
+	}
+	return media, uploadURL, nil
+}
+
+// VerifyMediaUpload marks the media as uploaded once the stored object matches what was
+// registered, or drops it when the upload failed or doesn't match.
+func (s *Server) VerifyMediaUpload(ctx context.Context, collectionID, mediaID uuid.UUID, success bool) error {
+	media, err := s.dB.GetMedia(ctx, database.GetMediaParams{ID: mediaID, CollectionID: collectionID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrMediaNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("error on getting media: %v", err)
+	}
+	if !success {
+		return s.dropMedia(ctx, collectionID, mediaID)
+	}
+
+	//the presigned URL pins type and size, the object is checked in case it came another way
+	size, contentType, err := s.storage.HeadFile(ctx, storage.MediaKey(mediaID.String()))
+	if err != nil {
+		return err
+	}
+	if size <= 0 || size > maxMediaSize || contentType != media.ContentType {
+		err = s.dropMedia(ctx, collectionID, mediaID)
+		if err != nil {
+			return err
+		}
+		return ErrInvalidUpload
+	}
+
+	_, err = s.dB.MarkMediaUploaded(ctx, database.MarkMediaUploadedParams{ID: mediaID, CollectionID: collectionID})
+	if err != nil {
+		return fmt.Errorf("error on verifying media: %v", err)
+	}
+	return nil
+}
+
+// dropMedia removes a media row and whatever was uploaded for it.
+func (s *Server) dropMedia(ctx context.Context, collectionID, mediaID uuid.UUID) error {
+	_, err := s.dB.DeleteMedia(ctx, database.DeleteMediaParams{ID: mediaID, CollectionID: collectionID})
+	if err != nil {
+		return fmt.Errorf("error on deleting media: %v", err)
+	}
+	err = s.storage.DeleteFile(ctx, storage.MediaKey(mediaID.String()))
+	if err != nil {
+		log.Printf("cannot delete media %v from storage: %v", mediaID, err)
+	}
+	return nil
+}
+
+func (s *Server) ListMedia(ctx context.Context, collectionID uuid.UUID) ([]Media, error) {
+	dbMedia, err := s.dB.ListCollectionMedia(ctx, collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("error on listing media: %v", err)
+	}
+	return s.mediaFromDB(ctx, dbMedia)
+}
+
+func (s *Server) mediaFromDB(ctx context.Context, dbMedia []database.CardMedium) ([]Media, error) {
+	var err error
+	media := make([]Media, len(dbMedia))
+	for i := range dbMedia {
+		m := dbMedia[i]
+		media[i] = Media{ID: m.ID, Page: int(m.Page.Int32), Kind: m.Kind, ContentType: m.ContentType, Reference: content.MediaScheme + m.ID.String()}
+		if m.CardID.Valid {
+			media[i].CardID = &m.CardID.UUID
+		}
+		if m.FileID.Valid {
+			media[i].FileID = &m.FileID.UUID
+		}
+		//presigning is local, no request to S3
+		media[i].URL, err = s.storage.GeneratePresignedGetUrl(ctx, storage.MediaKey(m.ID.String()), mediaUrlLifetime)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return media, nil
+}
+
+func (s *Server) DeleteMedia(ctx context.Context, collectionID, mediaID uuid.UUID) error {
+	n, err := s.dB.DeleteMedia(ctx, database.DeleteMediaParams{ID: mediaID, CollectionID: collectionID})
+	if err != nil {
+		return fmt.Errorf("error on deleting media: %v", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("media %v not found in collection", mediaID)
+	}
+	err = s.storage.DeleteFile(ctx, storage.MediaKey(mediaID.String()))
+	if err != nil {
+		log.Printf("cannot delete media %v from storage: %v", mediaID, err)
+	}
+	return nil
+}
+
+// attachMedia sets the attachments of each card and renders its media references
+// with download URLs. Cards can reference any media of their collection, only
+// the media the cards are attached to or reference gets a URL.
+func (s *Server) attachMedia(ctx context.Context, collectionID uuid.UUID, cards []Card) error {
+	cardIDs := make([]uuid.UUID, len(cards))
+	var mediaIDs []uuid.UUID
+	for i := range cards {
+		cardIDs[i] = cards[i].ID
+		for _, ref := range content.MediaIDs(cards[i].Front + "\n" + cards[i].Back) {
+			if id, err := uuid.Parse(ref); err == nil {
+				mediaIDs = append(mediaIDs, id)
+			}
+		}
+	}
+	dbMedia, err := s.dB.ListCardsMedia(ctx, database.ListCardsMediaParams{CollectionID: collectionID, CardIds: cardIDs, MediaIds: mediaIDs})
+	if err != nil {
+		return fmt.Errorf("error on listing media: %v", err)
+	}
+	media, err := s.mediaFromDB(ctx, dbMedia)
+	if err != nil {
+		return err
+	}
+
+	urls := make(map[string]string, len(media))
+	byCard := make(map[uuid.UUID][]Media)
+	for i := range media {
+		urls[media[i].ID.String()] = media[i].URL
+		if media[i].CardID != nil {
+			byCard[*media[i].CardID] = append(byCard[*media[i].CardID], media[i])
+		}
+	}
+	for i := range cards {
+		cards[i].Media = byCard[cards[i].ID]
+		cards[i].renderHTML(urls)
+	}
+	return nil
+}
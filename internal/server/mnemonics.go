@@ -0,0 +1,124 @@
+package server
+
+import (
+	"CueMind/internal/database"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidMnemonics = errors.New("invalid mnemonic request")
+
+var ErrMnemonicNotFound = errors.New("no such mnemonic")
+
+// failures that make a card a leech when a request doesn't say, like Anki
+const defaultLeechLapses = 8
+
+const (
+	MnemonicPending  = "pending"
+	MnemonicApproved = "approved"
+	MnemonicRejected = "rejected"
+)
+
+// NewMnemonicJob checks a request for mnemonics of the collection's leeches. The
+// job has no table, its ID only names the websocket the worker reports to.
+func (s *Server) NewMnemonicJob(ctx context.Context, userID, collectionID uuid.UUID, minLapses int) (*MnemonicJob, error) {
+	if minLapses == 0 {
+		minLapses = defaultLeechLapses
+	}
+	if minLapses < 1 || minLapses > 100 {
+		return nil, fmt.Errorf("%w: min_lapses must be between 1 and 100", ErrInvalidMnemonics)
+	}
+	dbCollection, err := s.dB.GetCollectionById(ctx, database.GetCollectionByIdParams{ID: collectionID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("error on gettig collection: %v", err)
+	}
+	return &MnemonicJob{ID: uuid.New(), CollectionName: dbCollection.Name, MinLapses: minLapses}, nil
+}
+
+// ListMnemonics returns the mnemonics of the collection with status, the pending ones when empty.
+func (s *Server) ListMnemonics(ctx context.Context, collectionID uuid.UUID, status string) ([]MnemonicSuggestion, error) {
+	if status == "" {
+		status = MnemonicPending
+	}
+	if status != MnemonicPending && status != MnemonicApproved && status != MnemonicRejected {
+		return nil, fmt.Errorf("%w: status must be pending, approved or rejected", ErrInvalidMnemonics)
+	}
+	rows, err := s.dB.ListCardMnemonics(ctx, database.ListCardMnemonicsParams{CollectionID: collectionID, Status: status})
+	if err != nil {
+		return nil, fmt.Errorf("error on getting mnemonics: %v", err)
+	}
+	mnemonics := make([]MnemonicSuggestion, len(rows))
+	for i, row := range rows {
+		card := Card{Front: row.Front, Back: row.Back, Format: row.ContentFormat}
+		card.renderHTML(nil)
+		mnemonics[i] = MnemonicSuggestion{
+			ID:        row.ID,
+			CardID:    row.CardID,
+			Front:     card.FrontHTML,
+			Back:      card.BackHTML,
+			Kind:      row.Kind,
+			Text:      row.Body,
+			Status:    row.Status,
+			CreatedAt: row.CreatedAt,
+		}
+	}
+	return mnemonics, nil
+}
+
+// ApproveMnemonic shows the mnemonic with its card, instead of the one approved before.
+func (s *Server) ApproveMnemonic(ctx context.Context, collectionID, mnemonicID uuid.UUID) error {
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := s.dB.WithTx(tx)
+
+	//a card has one approved mnemonic, the old one goes before the new one comes
+	err = qtx.RejectOtherMnemonics(ctx, database.RejectOtherMnemonicsParams{ID: mnemonicID, CollectionID: collectionID})
+	if err != nil {
+		return fmt.Errorf("error on rejecting mnemonics: %v", err)
+	}
+	n, err := qtx.ApproveCardMnemonic(ctx, database.ApproveCardMnemonicParams{ID: mnemonicID, CollectionID: collectionID})
+	if err != nil {
+		return fmt.Errorf("error on approving mnemonic: %v", err)
+	}
+	if n == 0 {
+		return ErrMnemonicNotFound
+	}
+	return tx.Commit()
+}
+
+// RejectMnemonic drops a suggestion, or takes an approved mnemonic off its card.
+func (s *Server) RejectMnemonic(ctx context.Context, collectionID, mnemonicID uuid.UUID) error {
+	n, err := s.dB.RejectCardMnemonic(ctx, database.RejectCardMnemonicParams{ID: mnemonicID, CollectionID: collectionID})
+	if err != nil {
+		return fmt.Errorf("error on rejecting mnemonic: %v", err)
+	}
+	if n == 0 {
+		return ErrMnemonicNotFound
+	}
+	return nil
+}
+
+// attachMnemonics sets the approved mnemonic of each card.
+func (s *Server) attachMnemonics(ctx context.Context, collectionID uuid.UUID, cards []Card) error {
+	rows, err := s.dB.ListApprovedMnemonics(ctx, collectionID)
+	if err != nil {
+		return fmt.Errorf("error on getting mnemonics: %v", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	byCard := make(map[uuid.UUID]string, len(rows))
+	for _, row := range rows {
+		byCard[row.CardID] = row.Body
+	}
+	for i := range cards {
+		cards[i].Mnemonic = byCard[cards[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ImportText creates cards from a pasted term/definition list, by default one
+// card per line with a tab between term and definition like Quizlet exports.
+func (s *Server) ImportText(ctx context.Context, collectionID uuid.UUID, req TextImport) (*ImportReport, error) {
+	termSep := content.Separator(req.TermSeparator, "\t")
+	cardSep := content.Separator(req.CardSeparator, "\n")
+	if termSep == cardSep {
+		return nil, fmt.Errorf("%w: term and card separators must differ", ErrInvalidImport)
+	}
+
+	pairs := content.ParsePairs(req.Content, termSep, cardSep)
+	if len(pairs) > maxImportRows {
+		return nil, fmt.Errorf("%w: %d cards, at most %d per import", ErrInvalidImport, len(pairs), maxImportRows)
+	}
+
+	report := &ImportReport{DryRun: req.DryRun, Total: len(pairs), Errors: []ImportRowError{}}
+	var rows []database.CardRow
+	for _, pair := range pairs {
+		if msg := validateCard(pair.Term, pair.Definition); msg != "" {
+			report.Errors = append(report.Errors, ImportRowError{Row: pair.Entry, Error: msg})
+			continue
+		}
+		rows = append(rows, database.CardRow{Front: pair.Term, Back: pair.Definition, Tags: req.Tags})
+	}
+
+	err := s.importCards(ctx, collectionID, rows, report)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrInvalidTemplate = errors.New("invalid prompt template")
+
+// saves of a template racing each other are tried this often
+const maxSaveAttempts = 3
+
+// GetPromptTemplate returns the template cards of the collection are generated
+// with, the built-in one as version 0 when it has none.
+func (s *Server) GetPromptTemplate(ctx context.Context, collectionID uuid.UUID) (*PromptTemplate, error) {
+	dbTemplate, err := s.dB.GetLatestPromptTemplate(ctx, collectionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &PromptTemplate{Version: 0, Body: llm.DefaultTemplate}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error on getting prompt template: %v", err)
+	}
+	template := toPromptTemplate(dbTemplate)
+	return &template, nil
+}
+
+func (s *Server) ListPromptTemplates(ctx context.Context, collectionID uuid.UUID) ([]PromptTemplate, error) {
+	dbTemplates, err := s.dB.ListPromptTemplates(ctx, collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("error on getting prompt templates: %v", err)
+	}
+	templates := make([]PromptTemplate, len(dbTemplates))
+	for i := range dbTemplates {
+		templates[i] = toPromptTemplate(dbTemplates[i])
+	}
+	return templates, nil
+}
+
+// SavePromptTemplate stores body as the newest version, the one the next uploads use.
+func (s *Server) SavePromptTemplate(ctx context.Context, collectionID uuid.UUID, body string) (*PromptTemplate, error) {
+	_, err := llm.ParseTemplate(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	//two saves at once can pick the same next version, the loser takes the one after
+	var dbTemplate database.PromptTemplate
+	for attempt := 1; ; attempt++ {
+		dbTemplate, err = s.dB.CreatePromptTemplate(ctx, database.CreatePromptTemplateParams{CollectionID: collectionID, Body: body})
+		if !isUniqueViolation(err) || attempt == maxSaveAttempts {
+			break
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error on saving prompt template: %v", err)
+	}
+	template := toPromptTemplate(dbTemplate)
+	return &template, nil
+}
+
+// RestorePromptTemplate saves an old version again as the newest one, history is never rewritten.
+func (s *Server) RestorePromptTemplate(ctx context.Context, collectionID uuid.UUID, version int32) (*PromptTemplate, error) {
+	//version 0 is the built-in template
+	body := llm.DefaultTemplate
+	if version != 0 {
+		dbTemplate, err := s.dB.GetPromptTemplateVersion(ctx, database.GetPromptTemplateVersionParams{CollectionID: collectionID, Version: version})
+		if err != nil {
+			return nil, fmt.Errorf("error on getting prompt template: %v", err)
+		}
+		body = dbTemplate.Body
+	}
+	return s.SavePromptTemplate(ctx, collectionID, body)
+}
+
+// PreviewPrompt renders the prompt the model would get with opts, from body or
+// the collection's current template when body is empty.
+func (s *Server) PreviewPrompt(ctx context.Context, collectionID uuid.UUID, body string, opts llm.Options) (string, error) {
+	err := opts.Validate()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	if body == "" {
+		current, err := s.GetPromptTemplate(ctx, collectionID)
+		if err != nil {
+			return "", err
+		}
+		body = current.Body
+	}
+	_, err = llm.ParseTemplate(body)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	opts.Template = body
+
+	opts.ExistingCards, err = s.dB.ListRecentCardFronts(ctx, database.ListRecentCardFrontsParams{CollectionID: collectionID, Limit: llm.MaxExistingCards})
+	if err != nil {
+		return "", fmt.Errorf("error on getting cards: %v", err)
+	}
+	return llm.RenderPrompt(opts)
+}
+
+func toPromptTemplate(t database.PromptTemplate) PromptTemplate {
+	return PromptTemplate{Version: t.Version, Body: t.Body, CreatedAt: t.CreatedAt}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
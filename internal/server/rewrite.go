@@ -0,0 +1,132 @@
+package server
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidRewrite = errors.New("invalid rewrite")
+
+var ErrCardNotFound = errors.New("no such card")
+
+// a split card becomes at most this many cards
+const maxRewriteCards = 20
+
+// RewriteCard asks the model for a new version of a card of the collection and
+// returns it as a proposal, the card is left as it is.
+func (s *Server) RewriteCard(ctx context.Context, userID, collectionID, cardID uuid.UUID, req RewriteRequest) (*Rewrite, error) {
+	dbCard, err := s.collectionCard(ctx, userID, collectionID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	rewrite := llm.RewriteRequest{
+		Action:       req.Action,
+		Front:        dbCard.Front,
+		Back:         dbCard.Back,
+		Source:       dbCard.SourceExcerpt.String,
+		Instructions: req.Instructions,
+	}
+	err = rewrite.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRewrite, err)
+	}
+
+	resp, err := s.generator.RewriteCard(ctx, rewrite)
+	if err != nil {
+		return nil, fmt.Errorf("error on rewriting card: %v", err)
+	}
+	if len(resp.Cards) > maxRewriteCards {
+		resp.Cards = resp.Cards[:maxRewriteCards]
+	}
+
+	cards := make([]Card, len(resp.Cards))
+	for i, c := range resp.Cards {
+		cards[i] = Card{Front: c.Front, Back: c.Back, Tags: dbCard.Tags, Status: CardStatusActive, Format: content.FormatMarkdown}
+		cards[i].renderHTML(nil)
+	}
+	original := cardFromDB(dbCard)
+	original.renderHTML(nil)
+	return &Rewrite{Action: req.Action, Original: original, Cards: cards}, nil
+}
+
+// AcceptRewrite saves a proposed rewrite: the first card replaces the original,
+// keeping its reviews, the others are created with its tags.
+func (s *Server) AcceptRewrite(ctx context.Context, userID, collectionID, cardID uuid.UUID, accept RewriteAccept) ([]Card, error) {
+	if len(accept.Cards) == 0 || len(accept.Cards) > maxRewriteCards {
+		return nil, fmt.Errorf("%w: between 1 and %d cards are needed", ErrInvalidRewrite, maxRewriteCards)
+	}
+	for i, c := range accept.Cards {
+		if c.Front == "" || c.Back == "" {
+			return nil, fmt.Errorf("%w: card %d has an empty front or back", ErrInvalidRewrite, i+1)
+		}
+	}
+	dbCard, err := s.collectionCard(ctx, userID, collectionID, cardID)
+	if err != nil {
+		return nil, err
+	}
+
+	//a split is saved whole or not at all
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := s.dB.WithTx(tx)
+
+	_, err = qtx.UpdateCard(ctx, database.UpdateCardParams{
+		ID:            cardID,
+		CollectionID:  collectionID,
+		Front:         accept.Cards[0].Front,
+		Back:          accept.Cards[0].Back,
+		ContentFormat: content.FormatMarkdown,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error on updating card:%v", err)
+	}
+	var created []Card
+	for _, c := range accept.Cards[1:] {
+		card := Card{Front: c.Front, Back: c.Back, Tags: dbCard.Tags, Format: content.FormatMarkdown}
+		err = s.createCard(ctx, qtx, collectionID, &card)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, card)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.GetCard(ctx, userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	cards := []Card{*updated}
+	for _, card := range created {
+		card.renderHTML(nil)
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+// collectionCard loads a card of the user, only if it is in the collection.
+func (s *Server) collectionCard(ctx context.Context, userID, collectionID, cardID uuid.UUID) (database.Card, error) {
+	dbCard, err := s.dB.GetCard(ctx, database.GetCardParams{ID: cardID, UserID: userID})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Card{}, ErrCardNotFound
+	}
+	if err != nil {
+		return database.Card{}, fmt.Errorf("error on getting card: %v", err)
+	}
+	if dbCard.CollectionID != collectionID {
+		return database.Card{}, ErrCardNotFound
+	}
+	return dbCard, nil
+}
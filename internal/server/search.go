@@ -0,0 +1,45 @@
+package server
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const maxSearchResults = 50
+
+// SearchCards runs a full-text search over the user's cards. collectionID and tags are optional filters,
+// a card must carry every given tag to match.
+func (s *Server) SearchCards(ctx context.Context, userID uuid.UUID, query string, collectionID *uuid.UUID, tags []string) ([]SearchResult, error) {
+	params := database.SearchCardsParams{
+		Query:      query,
+		UserID:     userID,
+		Tags:       content.NormalizeTags(tags),
+		MaxResults: maxSearchResults,
+	}
+	if collectionID != nil {
+		params.CollectionID = uuid.NullUUID{UUID: *collectionID, Valid: true}
+	}
+
+	rows, err := s.dB.SearchCards(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("error on searching cards: %v", err)
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i := range rows {
+		results[i] = SearchResult{
+			Card:           Card{ID: rows[i].ID, Front: rows[i].Front, Back: rows[i].Back, Tags: rows[i].Tags, Format: rows[i].ContentFormat},
+			CollectionID:   rows[i].CollectionID,
+			CollectionName: rows[i].CollectionName,
+			Rank:           rows[i].Rank,
+			FrontSnippet:   rows[i].FrontSnippet,
+			BackSnippet:    rows[i].BackSnippet,
+		}
+		results[i].renderHTML(nil)
+	}
+	return results, nil
+}
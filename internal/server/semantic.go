@@ -0,0 +1,115 @@
+package server
+
+import (
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const maxSemanticResults = 20
+
+var ErrEmbeddingNotFound = errors.New("card not found or not embedded yet")
+
+// without pgvector only the user's newest cards are compared, in process.
+// Larger libraries need the extension to search all of their cards.
+const maxInProcessEmbeddings = 5000
+
+// kept out of sqlc on purpose: the vector type only exists when pgvector is installed
+const nearestCardsPgvector = `
+SELECT cards.id, cards.front, cards.back, cards.collection_id, cards.tags, cards.content_format,
+    1 - (card_embeddings.embedding::vector <=> $3::real[]::vector) AS similarity
+FROM card_embeddings
+JOIN cards ON card_embeddings.card_id = cards.id
+JOIN collections ON cards.collection_id = collections.id
+WHERE collections.user_id = $1 AND card_embeddings.model = $2 AND cards.status = 'active'
+  AND cards.deleted_at IS NULL AND collections.deleted_at IS NULL
+  AND cards.id <> $4
+ORDER BY card_embeddings.embedding::vector <=> $3::real[]::vector
+LIMIT $5
+`
+
+func (s *Server) SemanticSearch(ctx context.Context, userID uuid.UUID, query string) ([]SemanticResult, error) {
+	vectors, err := s.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("error on embedding query: %v", err)
+	}
+	return s.nearestCards(ctx, userID, vectors[0], uuid.Nil)
+}
+
+func (s *Server) RelatedCards(ctx context.Context, userID, cardID uuid.UUID) ([]SemanticResult, error) {
+	embedding, err := s.dB.GetCardEmbedding(ctx, database.GetCardEmbeddingParams{ID: cardID, UserID: userID, Model: s.embedder.EmbeddingModel()})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEmbeddingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error on getting card embedding: %v", err)
+	}
+	return s.nearestCards(ctx, userID, embedding, cardID)
+}
+
+// nearestCards returns the user's cards closest to vector, skipping excludeID.
+func (s *Server) nearestCards(ctx context.Context, userID uuid.UUID, vector []float32, excludeID uuid.UUID) ([]SemanticResult, error) {
+	if s.pgvector {
+		return s.nearestCardsPgvector(ctx, userID, vector, excludeID)
+	}
+
+	rows, err := s.dB.ListUserCardEmbeddings(ctx, database.ListUserCardEmbeddingsParams{UserID: userID, Model: s.embedder.EmbeddingModel(), Limit: maxInProcessEmbeddings})
+	if err != nil {
+		return nil, fmt.Errorf("error on listing card embeddings: %v", err)
+	}
+	results := rankBySimilarity(rows, vector, excludeID)
+	for i := range results {
+		results[i].renderHTML(nil)
+	}
+	return results, nil
+}
+
+// rankBySimilarity orders rows by their similarity to vector, most similar
+// first, and keeps the first maxSemanticResults.
+func rankBySimilarity(rows []database.ListUserCardEmbeddingsRow, vector []float32, excludeID uuid.UUID) []SemanticResult {
+	results := make([]SemanticResult, 0, len(rows))
+	for i := range rows {
+		if rows[i].ID == excludeID {
+			continue
+		}
+		results = append(results, SemanticResult{
+			Card:         Card{ID: rows[i].ID, Front: rows[i].Front, Back: rows[i].Back, Tags: rows[i].Tags, Format: rows[i].ContentFormat},
+			CollectionID: rows[i].CollectionID,
+			Similarity:   llm.CosineSimilarity(vector, rows[i].Embedding),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Similarity > results[j].Similarity
+	})
+	if len(results) > maxSemanticResults {
+		results = results[:maxSemanticResults]
+	}
+	return results
+}
+
+func (s *Server) nearestCardsPgvector(ctx context.Context, userID uuid.UUID, vector []float32, excludeID uuid.UUID) ([]SemanticResult, error) {
+	rows, err := s.rawDB.QueryContext(ctx, nearestCardsPgvector, userID, s.embedder.EmbeddingModel(), pq.Array(vector), excludeID, maxSemanticResults)
+	if err != nil {
+		return nil, fmt.Errorf("error on querying nearest cards: %v", err)
+	}
+	defer rows.Close()
+
+	var results []SemanticResult
+	for rows.Next() {
+		var r SemanticResult
+		err = rows.Scan(&r.ID, &r.Front, &r.Back, &r.CollectionID, pq.Array(&r.Tags), &r.Format, &r.Similarity)
+		if err != nil {
+			return nil, fmt.Errorf("error on scanning nearest cards: %v", err)
+		}
+		r.renderHTML(nil)
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRankBySimilarity(t *testing.T) {
+	fronts := []string{
+		"the french revolution began in 1789",
+		"what does the mitochondria produce",
+		"krebs cycle steps in the mitochondria",
+		"glycolysis in the cytoplasm",
+	}
+	embedder := llm.FakeEmbedder{Dimensions: 256}
+	vectors, err := embedder.Embed(context.Background(), append(fronts, "mitochondria krebs cycle"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make([]database.ListUserCardEmbeddingsRow, len(fronts))
+	for i := range fronts {
+		rows[i] = database.ListUserCardEmbeddingsRow{ID: uuid.New(), Front: fronts[i], Embedding: vectors[i]}
+	}
+	query := vectors[len(fronts)]
+
+	tests := []struct {
+		name      string
+		excludeID uuid.UUID
+		count     int
+		want      []string
+	}{
+		{
+			name:  "most similar first",
+			count: 4,
+			want:  []string{fronts[2], fronts[1]},
+		},
+		{
+			name:      "excluded card left out",
+			excludeID: rows[2].ID,
+			count:     3,
+			want:      []string{fronts[1]},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := rankBySimilarity(rows, query, tt.excludeID)
+			if len(results) != tt.count {
+				t.Fatalf("got %d results, want %d", len(results), tt.count)
+			}
+			for i, front := range tt.want {
+				if results[i].Front != front {
+					t.Errorf("result %d is %q, want %q", i, results[i].Front, front)
+				}
+			}
+			for i := 1; i < len(results); i++ {
+				if results[i].Similarity > results[i-1].Similarity {
+					t.Errorf("result %d more similar than result %d", i, i-1)
+				}
+			}
+			for _, r := range results {
+				if r.ID == tt.excludeID {
+					t.Errorf("excluded card %v in results", r.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestRankBySimilarityLimit(t *testing.T) {
+	rows := make([]database.ListUserCardEmbeddingsRow, maxSemanticResults+5)
+	for i := range rows {
+		rows[i] = database.ListUserCardEmbeddingsRow{ID: uuid.New(), Embedding: []float32{1, float32(i)}}
+	}
+	results := rankBySimilarity(rows, []float32{1, 0}, uuid.Nil)
+	if len(results) != maxSemanticResults {
+		t.Fatalf("got %d results, want %d", len(results), maxSemanticResults)
+	}
+	if results[0].ID != rows[0].ID {
+		t.Errorf("closest card is %v, want %v", results[0].ID, rows[0].ID)
+	}
+}
@@ -1,24 +1,35 @@
 package server
 
 import (
+	"CueMind/internal/content"
 	"CueMind/internal/database"
+	"CueMind/internal/llm"
 	"CueMind/internal/storage"
 	"context"
 	"database/sql"
 	"fmt"
 	"io"
+	"log"
 
 	"github.com/google/uuid"
 )
 
 type Server struct {
-	rawDB   *sql.DB
-	dB      *database.Queries
-	storage *storage.Storage
+	rawDB    *sql.DB
+	dB       *database.Queries
+	storage  *storage.Storage
+	embedder llm.Embedder
+	// for requests answered right away, like card rewrites
+	generator llm.CardGenerator
+	pgvector  bool
 }
 
-func New(db *database.Queries, storage *storage.Storage, rawSql *sql.DB) *Server {
-	return &Server{dB: db, storage: storage, rawDB: rawSql}
+func New(db *database.Queries, storage *storage.Storage, rawSql *sql.DB, embedder llm.Embedder, generator llm.CardGenerator) *Server {
+	pgvector, err := db.HasVectorExtension(context.Background())
+	if err != nil {
+		log.Printf("cannot check for pgvector, using in-process similarity: %v", err)
+	}
+	return &Server{dB: db, storage: storage, rawDB: rawSql, embedder: embedder, generator: generator, pgvector: pgvector}
 }
 
 func (s *Server) CraeteUser(ctx context.Context, regData RegisterData) (*User, error) {
@@ -63,20 +74,40 @@ func (s *Server) GetCollection(ctx context.Context, userId uuid.UUID, collectId
 		return nil, fmt.Errorf("error on counting cards: %v", err)
 	}
 
+	//file names for card sources, files in the trash still name the cards made from them
+	dbFiles, err := s.dB.ListCollectionFileNames(ctx, collectId)
+	if err != nil {
+		return nil, fmt.Errorf("error on getting files: %v", err)
+	}
+	fileNames := make(map[uuid.UUID]string, len(dbFiles))
+	for i := range dbFiles {
+		fileNames[dbFiles[i].ID] = dbFiles[i].FileName.String
+	}
+
 	cards := make([]Card, len(dbCards))
 
 	for i := range dbCards {
-		var card Card
-		card.Back = dbCards[i].Back
-		card.Front = dbCards[i].Front
-		card.ID = dbCards[i].ID
+		card := cardFromDB(dbCards[i])
+		if card.Source != nil {
+			card.Source.FileName = fileNames[card.Source.FileID]
+		}
 		cards[i] = card
 	}
+	err = s.attachMedia(ctx, collectId, cards)
+	if err != nil {
+		return nil, err
+	}
+	err = s.attachMnemonics(ctx, collectId, cards)
+	if err != nil {
+		return nil, err
+	}
 	collection := Collection{Name: dbCollection.Name, ID: dbCollection.ID, CardNumbers: count}
 	return &CollectionFull{Collection: collection, Cards: cards}, nil
 
 }
 
+// DeleteCollection moves the collection with its cards and files to the trash.
+// NOW() is fixed per transaction, so all rows share one deleted_at for RestoreCollection.
 func (s *Server) DeleteCollection(ctx context.Context, collectionID, userID uuid.UUID) error {
 	//create new transaction
 	tx, err := s.rawDB.BeginTx(ctx, nil)
@@ -144,15 +175,92 @@ func (s *Server) GetCard(ctx context.Context, userID, cardID uuid.UUID) (*Card,
 	if err != nil {
 		return nil, fmt.Errorf("error on getting card: %v", err)
 	}
-	return &Card{Front: dbCard.Front, Back: dbCard.Back, ID: cardID}, nil
+	card := cardFromDB(dbCard)
+	if card.Source != nil {
+		fileName, err := s.dB.GetFileName(ctx, card.Source.FileID)
+		if err != nil {
+			return nil, fmt.Errorf("error on getting card source: %v", err)
+		}
+		card.Source.FileName = fileName.String
+	}
+	cards := []Card{card}
+	err = s.attachMedia(ctx, dbCard.CollectionID, cards)
+	if err != nil {
+		return nil, err
+	}
+	err = s.attachMnemonics(ctx, dbCard.CollectionID, cards)
+	if err != nil {
+		return nil, err
+	}
+	return &cards[0], nil
+}
+
+// cardFromDB leaves FrontHTML and BackHTML empty, callers render once they
+// know the media URLs.
+func cardFromDB(dbCard database.Card) Card {
+	card := Card{ID: dbCard.ID, Front: dbCard.Front, Back: dbCard.Back, Tags: dbCard.Tags, Status: dbCard.Status, Format: dbCard.ContentFormat}
+	if dbCard.DuplicateOf.Valid {
+		card.DuplicateOf = &dbCard.DuplicateOf.UUID
+	}
+	if dbCard.FileID.Valid {
+		card.Source = &CardSource{
+			FileID:    dbCard.FileID.UUID,
+			PageStart: int(dbCard.SourcePageStart.Int32),
+			PageEnd:   int(dbCard.SourcePageEnd.Int32),
+			Excerpt:   dbCard.SourceExcerpt.String,
+		}
+	}
+	return card
+}
+
+// renderHTML fills FrontHTML and BackHTML, media references are swapped for mediaURLs (media ID -> URL).
+func (c *Card) renderHTML(mediaURLs map[string]string) {
+	front, back := c.Front, c.Back
+	if c.Format == content.FormatMarkdown {
+		front = content.ResolveMedia(front, mediaURLs)
+		back = content.ResolveMedia(back, mediaURLs)
+	}
+	c.FrontHTML = content.RenderHTML(front, c.Format)
+	c.BackHTML = content.RenderHTML(back, c.Format)
 }
 
 func (s *Server) CreateCard(ctx context.Context, collectionID uuid.UUID, card *Card) error {
-	cardID, err := s.dB.CreateCard(ctx, database.CreateCardParams{Front: card.Front, Back: card.Back, CollectionID: collectionID})
+	if card.Format == "" {
+		card.Format = content.FormatPlain
+	}
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = s.createCard(ctx, s.dB.WithTx(tx), collectionID, card)
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	card.renderHTML(nil)
+	return nil
+}
+
+// createCard saves a new active card and its tags with qtx.
+func (s *Server) createCard(ctx context.Context, qtx *database.Queries, collectionID uuid.UUID, card *Card) error {
+	cardID, err := qtx.CreateCard(ctx, database.CreateCardParams{Front: card.Front, Back: card.Back, CollectionID: collectionID, ContentFormat: card.Format})
 	if err != nil {
 		return fmt.Errorf("error on creating card: %v", err)
 	}
 	card.ID = cardID
+	card.Status = CardStatusActive
+
+	card.Tags = content.NormalizeTags(card.Tags)
+	if len(card.Tags) > 0 {
+		err = qtx.SetCardTags(ctx, database.SetCardTagsParams{Tags: card.Tags, ID: cardID})
+		if err != nil {
+			return fmt.Errorf("error on setting card tags: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -165,11 +273,27 @@ func (s *Server) DeleteCard(ctx context.Context, cardID, collectionID uuid.UUID)
 	return nil
 }
 
-func (s *Server) UpdateCard(ctx context.Context, cardID uuid.UUID, front, back string) error {
-	err := s.dB.UpdateCard(ctx, database.UpdateCardParams{ID: cardID, Front: front, Back: back})
+// UpdateCard keeps the stored content format when format is empty, and the
+// stored tags when tags is nil. It returns ErrCardNotFound when the card isn't in collectionID.
+func (s *Server) UpdateCard(ctx context.Context, collectionID, cardID uuid.UUID, front, back string, tags *[]string, format string) error {
+	var normalized []string
+	if tags != nil {
+		normalized = content.NormalizeTags(*tags)
+	}
+	n, err := s.dB.UpdateCard(ctx, database.UpdateCardParams{
+		ID:            cardID,
+		CollectionID:  collectionID,
+		Front:         front,
+		Back:          back,
+		Tags:          normalized,
+		ContentFormat: format,
+	})
 	if err != nil {
 		return fmt.Errorf("error on updating card:%v", err)
 	}
+	if n == 0 {
+		return ErrCardNotFound
+	}
 	return nil
 }
 
@@ -194,6 +318,33 @@ func (s *Server) DeleteFile(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// DeleteCollectionFile moves an uploaded file to the trash, and the cards generated from it too when withCards is set.
+func (s *Server) DeleteCollectionFile(ctx context.Context, collectionID, fileID uuid.UUID, withCards bool) error {
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.dB.WithTx(tx)
+
+	n, err := qtx.DeleteCollectionFile(ctx, database.DeleteCollectionFileParams{ID: fileID, CollectionID: collectionID})
+	if err != nil {
+		return fmt.Errorf("error on deleting file: %v", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("file %v not found in collection", fileID)
+	}
+
+	if withCards {
+		err = qtx.DeleteFileCards(ctx, database.DeleteFileCardsParams{FileID: uuid.NullUUID{UUID: fileID, Valid: true}, CollectionID: collectionID})
+		if err != nil {
+			return fmt.Errorf("error on deleting cards of file: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
 func (s *Server) CompeleteFileDetails(ctx context.Context, file File) error {
 	err := s.dB.CompeleteFileDetails(ctx, database.CompeleteFileDetailsParams{FileName: sql.NullString{String: file.Filename, Valid: true}, CollectionID: file.CollectionID, UserID: file.UserID, ID: file.ID, Format: sql.NullString{String: file.Format, Valid: true}})
 	if err != nil {
@@ -0,0 +1,324 @@
+package server
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// rows per import request, bigger sheets have to be split
+const maxImportRows = 5000
+
+// rows written between flushes while streaming an export
+const exportFlushRows = 100
+
+var (
+	ErrInvalidImport     = errors.New("invalid import")
+	ErrCollectionDeleted = errors.New("the collection was deleted")
+)
+
+// first characters that make spreadsheet apps read a cell as a formula
+const formulaStart = "=+-@\t\r"
+
+var dueLayouts = []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05"}
+
+// CardTable is a collection export as CSV or TSV, Write streams it.
+type CardTable struct {
+	FileName     string
+	ContentType  string
+	comma        rune
+	db           *database.Queries
+	collectionID uuid.UUID
+}
+
+func (s *Server) ExportTable(ctx context.Context, userID, collectionID uuid.UUID, format string) (*CardTable, error) {
+	comma, ok := tableComma(format)
+	if !ok {
+		return nil, ErrUnknownExportFormat
+	}
+
+	dbCollection, err := s.dB.GetCollectionById(ctx, database.GetCollectionByIdParams{ID: collectionID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("error on gettig collection: %v", err)
+	}
+
+	table := &CardTable{FileName: dbCollection.Name + "." + format, ContentType: "text/csv; charset=utf-8", comma: comma, db: s.dB, collectionID: collectionID}
+	if format == ExportTSV {
+		table.ContentType = "text/tab-separated-values; charset=utf-8"
+	}
+	return table, nil
+}
+
+// Write writes a header and one line per card: front, back, tags, due date and
+// interval in days, as the cards are read. Cards that were never reviewed have
+// no interval.
+func (t *CardTable) Write(ctx context.Context, w io.Writer) error {
+	out := csv.NewWriter(w)
+	out.Comma = t.comma
+
+	err := out.Write([]string{"front", "back", "tags", "due", "interval"})
+	if err != nil {
+		return err
+	}
+	n := 0
+	err = t.db.EachCardForExport(ctx, t.collectionID, func(row database.ListCardsForExportRow) error {
+		due, interval := "", ""
+		if row.DueDate.Valid {
+			due = row.DueDate.Time.Format("2006-01-02")
+		}
+		if row.IntervalDays.Valid {
+			interval = strconv.Itoa(int(row.IntervalDays.Int32))
+		}
+		err := out.Write([]string{escapeCell(row.Front), escapeCell(row.Back), escapeCell(strings.Join(row.Tags, ", ")), due, interval})
+		if err != nil {
+			return err
+		}
+		n++
+		if n%exportFlushRows == 0 {
+			out.Flush()
+		}
+		return out.Error()
+	})
+	if err != nil {
+		return fmt.Errorf("error on getting cards: %v", err)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// escapeCell keeps spreadsheets from running a cell as a formula by putting a
+// quote in front of text that starts like one. unescapeCell takes it off again
+// on import.
+func escapeCell(text string) string {
+	if text != "" && strings.ContainsRune(formulaStart, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+func unescapeCell(text string) string {
+	if len(text) > 1 && text[0] == '\'' && strings.ContainsRune(formulaStart, rune(text[1])) {
+		return text[1:]
+	}
+	return text
+}
+
+// ImportTable creates cards from the rows of a CSV or TSV table. Errors wrapping
+// ErrInvalidImport mean the table itself can't be read.
+func (s *Server) ImportTable(ctx context.Context, collectionID uuid.UUID, req TableImport) (*ImportReport, error) {
+	comma, ok := tableComma(req.Format)
+	if !ok {
+		return nil, fmt.Errorf("%w: format must be csv or tsv", ErrInvalidImport)
+	}
+
+	reader := csv.NewReader(strings.NewReader(req.Content))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	//tsv exports rarely quote, a stray quote is part of the text
+	reader.LazyQuotes = req.Format == ExportTSV
+	var records [][]string
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		//blank lines are skipped by the reader, report rows by their line in the file
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+
+	var header []string
+	if req.HasHeader && len(records) > 0 {
+		header, records, lines = records[0], records[1:], lines[1:]
+	}
+	if len(records) > maxImportRows {
+		return nil, fmt.Errorf("%w: %d rows, at most %d per import", ErrInvalidImport, len(records), maxImportRows)
+	}
+
+	front, err := resolveColumn(req.Columns.Front, "front", "1", header)
+	if err != nil {
+		return nil, err
+	}
+	back, err := resolveColumn(req.Columns.Back, "back", "2", header)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := resolveColumn(req.Columns.Tags, "tags", "", header)
+	if err != nil {
+		return nil, err
+	}
+	due, err := resolveColumn(req.Columns.Due, "due", "", header)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: req.DryRun, Total: len(records), Errors: []ImportRowError{}}
+	var rows []database.CardRow
+	for i, record := range records {
+		field := func(col int) string {
+			if col < 0 || col >= len(record) {
+				return ""
+			}
+			return unescapeCell(strings.TrimSpace(record[col]))
+		}
+		//a row of empty cells in a spreadsheet isn't worth reporting
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			report.Total--
+			continue
+		}
+
+		row := database.CardRow{Front: field(front), Back: field(back), Tags: splitTags(field(tags))}
+		rowErr := validateCard(row.Front, row.Back)
+		if text := field(due); rowErr == "" && text != "" {
+			row.DueDate, ok = parseDue(text)
+			if !ok {
+				rowErr = fmt.Sprintf("due %q is not a date", text)
+			}
+		}
+		if rowErr != "" {
+			report.Errors = append(report.Errors, ImportRowError{Row: lines[i], Error: rowErr})
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	err = s.importCards(ctx, collectionID, rows, report)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importCards saves the valid rows of an import in one batch, a dry run only
+// previews them.
+func (s *Server) importCards(ctx context.Context, collectionID uuid.UUID, rows []database.CardRow, report *ImportReport) error {
+	report.Valid = len(rows)
+	batch := database.InsertCardsParams{CollectionID: collectionID, Status: CardStatusActive, ContentFormat: content.FormatPlain}
+	report.Cards = make([]Card, len(rows))
+	for i := range rows {
+		rows[i].Tags = content.NormalizeTags(rows[i].Tags)
+		card := Card{Front: rows[i].Front, Back: rows[i].Back, Tags: rows[i].Tags, Status: CardStatusActive, Format: content.FormatPlain}
+		if !report.DryRun {
+			card.ID = batch.Add(rows[i])
+		}
+		card.renderHTML(nil)
+		report.Cards[i] = card
+	}
+	if report.DryRun || len(rows) == 0 {
+		return nil
+	}
+
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := s.dB.WithTx(tx)
+
+	//keeps the collection from being deleted until the cards are in
+	_, err = qtx.LockLiveCollection(ctx, collectionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCollectionDeleted
+	}
+	if err != nil {
+		return fmt.Errorf("error on locking collection: %v", err)
+	}
+	err = qtx.InsertCards(ctx, batch)
+	if err != nil {
+		return fmt.Errorf("error on importing cards: %v", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	report.Created = len(rows)
+	return nil
+}
+
+// CreatedIDs returns the ids of the cards the import saved, none for a dry run.
+func (r *ImportReport) CreatedIDs() []uuid.UUID {
+	if r.DryRun {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(r.Cards))
+	for i := range r.Cards {
+		ids[i] = r.Cards[i].ID
+	}
+	return ids
+}
+
+func tableComma(format string) (rune, bool) {
+	switch format {
+	case ExportCSV:
+		return ',', true
+	case ExportTSV:
+		return '\t', true
+	}
+	return 0, false
+}
+
+// resolveColumn returns the index of a mapped column, -1 for an optional column
+// that isn't there. Without a mapping the column is looked up by its usual header
+// name, then falls back to position.
+func resolveColumn(mapping, name, position string, header []string) (int, error) {
+	if mapping == "" {
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i, nil
+			}
+		}
+		if position == "" {
+			return -1, nil
+		}
+		mapping = position
+	}
+
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(mapping)) {
+			return i, nil
+		}
+	}
+	n, err := strconv.Atoi(mapping)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%w: no column %q for %v", ErrInvalidImport, mapping, name)
+	}
+	return n - 1, nil
+}
+
+func validateCard(front, back string) string {
+	switch {
+	case front == "":
+		return "front is empty"
+	case back == "":
+		return "back is empty"
+	}
+	return ""
+}
+
+func parseDue(text string) (sql.NullTime, bool) {
+	for _, layout := range dueLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return sql.NullTime{Time: t, Valid: true}, true
+		}
+	}
+	return sql.NullTime{}, false
+}
+
+// splitTags reads the tags cell, tags are separated by commas or semicolons.
+func splitTags(cell string) []string {
+	return strings.FieldsFunc(cell, func(r rune) bool { return r == ',' || r == ';' })
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestImportTableDryRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     TableImport
+		valid   int
+		errRows []int
+		fronts  []string
+	}{
+		{
+			name:   "positional csv",
+			req:    TableImport{Format: ExportCSV, Content: "dog,Hund\ncat,Katze\n"},
+			valid:  2,
+			fronts: []string{"dog", "cat"},
+		},
+		{
+			name:    "header and mapping",
+			req:     TableImport{Format: ExportCSV, HasHeader: true, Columns: ColumnMapping{Front: "term", Back: "3"}, Content: "term,x,meaning\ndog,,Hund\n,,Katze\n"},
+			valid:   1,
+			errRows: []int{3},
+			fronts:  []string{"dog"},
+		},
+		{
+			name:   "tsv with stray quote",
+			req:    TableImport{Format: ExportTSV, Content: "6\" ruler\tshort\n"},
+			valid:  1,
+			fronts: []string{"6\" ruler"},
+		},
+		{
+			name:    "bad due date",
+			req:     TableImport{Format: ExportCSV, HasHeader: true, Content: "front,back,due\na,b,tomorrow\nc,d,2025-01-02\n"},
+			valid:   1,
+			errRows: []int{2},
+			fronts:  []string{"c"},
+		},
+		{
+			name:   "escaped formula",
+			req:    TableImport{Format: ExportCSV, Content: "'=1+1,two\n"},
+			valid:  1,
+			fronts: []string{"=1+1"},
+		},
+		{
+			name:   "empty rows skipped",
+			req:    TableImport{Format: ExportCSV, Content: "a,b\n,\n"},
+			valid:  1,
+			fronts: []string{"a"},
+		},
+	}
+
+	s := &Server{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.DryRun = true
+			report, err := s.ImportTable(context.Background(), uuid.New(), tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Valid != tt.valid || report.Created != 0 {
+				t.Errorf("valid %d created %d, want %d and 0", report.Valid, report.Created, tt.valid)
+			}
+			var rows []int
+			for _, e := range report.Errors {
+				rows = append(rows, e.Row)
+			}
+			if !reflect.DeepEqual(rows, tt.errRows) {
+				t.Errorf("errors on rows %v, want %v", rows, tt.errRows)
+			}
+			var fronts []string
+			for _, c := range report.Cards {
+				fronts = append(fronts, c.Front)
+			}
+			if !reflect.DeepEqual(fronts, tt.fronts) {
+				t.Errorf("fronts %q, want %q", fronts, tt.fronts)
+			}
+			if report.CreatedIDs() != nil {
+				t.Error("a dry run created cards")
+			}
+		})
+	}
+}
+
+func TestImportTableInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		req  TableImport
+	}{
+		{name: "format", req: TableImport{Format: "xlsx", Content: "a,b"}},
+		{name: "broken quotes", req: TableImport{Format: ExportCSV, Content: "\"a,b\nc"}},
+		{name: "unknown column", req: TableImport{Format: ExportCSV, HasHeader: true, Columns: ColumnMapping{Front: "nope"}, Content: "front,back\na,b"}},
+	}
+	s := &Server{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.ImportTable(context.Background(), uuid.New(), tt.req)
+			if !errors.Is(err, ErrInvalidImport) {
+				t.Errorf("got %v, want ErrInvalidImport", err)
+			}
+		})
+	}
+}
+
+func TestEscapeCell(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{text: "=SUM(A1)", want: "'=SUM(A1)"},
+		{text: "+1", want: "'+1"},
+		{text: "-x", want: "'-x"},
+		{text: "@me", want: "'@me"},
+		{text: "plain", want: "plain"},
+		{text: "", want: ""},
+	}
+	for _, tt := range tests {
+		got := escapeCell(tt.text)
+		if got != tt.want {
+			t.Errorf("escapeCell(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if back := unescapeCell(got); back != tt.text {
+			t.Errorf("unescapeCell(%q) = %q, want %q", got, back, tt.text)
+		}
+	}
+	if got := unescapeCell("'quoted"); got != "'quoted" {
+		t.Errorf("unescapeCell kept %q", got)
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	got := splitTags("bio, cell;energy")
+	if !reflect.DeepEqual(got, []string{"bio", " cell", "energy"}) {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"CueMind/internal/database"
+	"CueMind/internal/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrNotInTrash = errors.New("item is not in the trash")
+
+func (s *Server) GetTrash(ctx context.Context, userID uuid.UUID) (*Trash, error) {
+	dbCollections, err := s.dB.ListDeletedCollections(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error on listing deleted collections: %v", err)
+	}
+	dbCards, err := s.dB.ListDeletedCards(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error on listing deleted cards: %v", err)
+	}
+	dbFiles, err := s.dB.ListDeletedFiles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error on listing deleted files: %v", err)
+	}
+
+	trash := Trash{
+		Collections: make([]DeletedCollection, len(dbCollections)),
+		Cards:       make([]DeletedCard, len(dbCards)),
+		Files:       make([]DeletedFile, len(dbFiles)),
+	}
+	for i := range dbCollections {
+		trash.Collections[i] = DeletedCollection{
+			Collection: Collection{ID: dbCollections[i].ID, Name: dbCollections[i].Name},
+			DeletedAt:  dbCollections[i].DeletedAt.Time,
+		}
+	}
+	for i := range dbCards {
+		trash.Cards[i] = DeletedCard{
+			Card:         cardFromDB(dbCards[i]),
+			CollectionID: dbCards[i].CollectionID,
+			DeletedAt:    dbCards[i].DeletedAt.Time,
+		}
+		trash.Cards[i].renderHTML(nil)
+	}
+	for i := range dbFiles {
+		trash.Files[i] = DeletedFile{
+			File: File{
+				ID:           dbFiles[i].ID,
+				Filename:     dbFiles[i].FileName.String,
+				CollectionID: dbFiles[i].CollectionID,
+				UserID:       dbFiles[i].UserID,
+				Format:       dbFiles[i].Format.String,
+			},
+			DeletedAt: dbFiles[i].DeletedAt.Time,
+		}
+	}
+	return &trash, nil
+}
+
+func (s *Server) RestoreCollection(ctx context.Context, collectionID, userID uuid.UUID) error {
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.dB.WithTx(tx)
+
+	//children first, they are matched against the collection's deleted_at
+	err = qtx.RestoreAllCards(ctx, collectionID)
+	if err != nil {
+		return fmt.Errorf("error on restoring cards: %v", err)
+	}
+	err = qtx.RestoreAllFiles(ctx, collectionID)
+	if err != nil {
+		return fmt.Errorf("error on restoring files: %v", err)
+	}
+
+	n, err := qtx.RestoreCollection(ctx, database.RestoreCollectionParams{ID: collectionID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("error on restoring collection: %v", err)
+	}
+	if n == 0 {
+		return ErrNotInTrash
+	}
+	return tx.Commit()
+}
+
+func (s *Server) RestoreCard(ctx context.Context, cardID, userID uuid.UUID) error {
+	n, err := s.dB.RestoreCard(ctx, database.RestoreCardParams{ID: cardID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("error on restoring card: %v", err)
+	}
+	if n == 0 {
+		return ErrNotInTrash
+	}
+	return nil
+}
+
+// RestoreFile brings back the file, and the cards that were deleted along with it.
+func (s *Server) RestoreFile(ctx context.Context, fileID, userID uuid.UUID) error {
+	tx, err := s.rawDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := s.dB.WithTx(tx)
+
+	//cards first, they are matched against the file's deleted_at
+	err = qtx.RestoreFileCards(ctx, fileID)
+	if err != nil {
+		return fmt.Errorf("error on restoring cards of file: %v", err)
+	}
+	n, err := qtx.RestoreFile(ctx, database.RestoreFileParams{ID: fileID, UserID: userID})
+	if err != nil {
+		return fmt.Errorf("error on restoring file: %v", err)
+	}
+	if n == 0 {
+		return ErrNotInTrash
+	}
+	return tx.Commit()
+}
+
+// PurgeTrash permanently removes everything that has been in the trash longer than retention,
+// including the S3 objects of purged files and media.
+func (s *Server) PurgeTrash(ctx context.Context, retention time.Duration) error {
+	cutoff := sql.NullTime{Time: time.Now().Add(-retention), Valid: true}
+
+	err := s.dB.PurgeCards(ctx, cutoff)
+	if err != nil {
+		return fmt.Errorf("error on purging cards: %v", err)
+	}
+
+	fileIDs, err := s.dB.ListPurgeableFiles(ctx, cutoff)
+	if err != nil {
+		return fmt.Errorf("error on listing purgeable files: %v", err)
+	}
+	for _, id := range fileIDs {
+		//file ID is the object key in S3
+		err = s.storage.DeleteFile(ctx, id.String())
+		if err != nil {
+			//the row stays for the next run, its collection waits for it
+			log.Printf("cannot delete file %v from storage: %v", id, err)
+			continue
+		}
+		err = s.dB.PurgeFile(ctx, id)
+		if err != nil {
+			return fmt.Errorf("error on purging file: %v", err)
+		}
+	}
+
+	//media rows go with the collection, their objects have to be removed first.
+	//collections that still have files are left for a later run
+	media, err := s.dB.ListPurgeableMedia(ctx, cutoff)
+	if err != nil {
+		return fmt.Errorf("error on listing purgeable media: %v", err)
+	}
+	kept := []uuid.UUID{}
+	for _, m := range media {
+		err = s.storage.DeleteFile(ctx, storage.MediaKey(m.ID.String()))
+		if err != nil {
+			//the collection keeps its media rows until every object is gone
+			log.Printf("cannot delete media %v from storage: %v", m.ID, err)
+			kept = append(kept, m.CollectionID)
+		}
+	}
+
+	err = s.dB.PurgeCollections(ctx, database.PurgeCollectionsParams{DeletedAt: cutoff, Kept: kept})
+	if err != nil {
+		return fmt.Errorf("error on purging collections: %v", err)
+	}
+	return nil
+}
+
+func (s *Server) StartTrashPurger(retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := s.PurgeTrash(context.Background(), retention)
+		if err != nil {
+			log.Printf("trash purge failed: %v", err)
+		}
+		err = s.PurgePdfExports(context.Background(), pdfExportLifetime)
+		if err != nil {
+			log.Printf("pdf export purge failed: %v", err)
+		}
+		<-ticker.C
+	}
+}
@@ -1,6 +1,11 @@
 package server
 
-import "github.com/google/uuid"
+import (
+	"CueMind/internal/llm"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Collection struct {
 	ID          uuid.UUID `json:"id"`
@@ -14,9 +19,43 @@ type CollectionFull struct {
 }
 
 type Card struct {
-	ID    uuid.UUID `json:"id"`
-	Front string    `json:"front"`
-	Back  string    `json:"back"`
+	ID          uuid.UUID  `json:"id"`
+	Front       string     `json:"front"`
+	Back        string     `json:"back"`
+	Tags        []string   `json:"tags"`
+	DuplicateOf *uuid.UUID `json:"duplicate_of,omitempty"`
+	// a draft whose back replaces the back of DuplicateOf when accepted
+	Merge  bool        `json:"merge,omitempty"`
+	Status string      `json:"status"`
+	Source *CardSource `json:"source,omitempty"`
+	// plain or markdown, the HTML fields are rendered and sanitized from Front and Back
+	Format    string  `json:"format"`
+	FrontHTML string  `json:"front_html"`
+	BackHTML  string  `json:"back_html"`
+	Media     []Media `json:"media,omitempty"`
+	// the approved mnemonic, shown with the card during review
+	Mnemonic string `json:"mnemonic,omitempty"`
+}
+
+// Media is an image or audio attachment. Card text references it as media:<id>.
+type Media struct {
+	ID          uuid.UUID  `json:"id"`
+	CardID      *uuid.UUID `json:"card_id,omitempty"`
+	FileID      *uuid.UUID `json:"file_id,omitempty"`
+	Page        int        `json:"page,omitempty"`
+	Kind        string     `json:"kind"`
+	ContentType string     `json:"content_type"`
+	Reference   string     `json:"reference"`
+	URL         string     `json:"url"`
+}
+
+// CardSource points to the part of an uploaded file a generated card was made from.
+type CardSource struct {
+	FileID    uuid.UUID `json:"file_id"`
+	FileName  string    `json:"file_name"`
+	PageStart int       `json:"page_start,omitempty"`
+	PageEnd   int       `json:"page_end,omitempty"`
+	Excerpt   string    `json:"excerpt,omitempty"`
 }
 
 type RegisterData struct {
@@ -43,3 +82,199 @@ type File struct {
 	UserID       uuid.UUID `json:"user_id"`
 	Format       string    `json:"format"`
 }
+
+type DeletedCollection struct {
+	Collection
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type DeletedCard struct {
+	Card
+	CollectionID uuid.UUID `json:"collection_id"`
+	DeletedAt    time.Time `json:"deleted_at"`
+}
+
+type DeletedFile struct {
+	File
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type Trash struct {
+	Collections []DeletedCollection `json:"collections"`
+	Cards       []DeletedCard       `json:"cards"`
+	Files       []DeletedFile       `json:"files"`
+}
+
+type SearchResult struct {
+	Card
+	CollectionID   uuid.UUID `json:"collection_id"`
+	CollectionName string    `json:"collection_name"`
+	Rank           float32   `json:"rank"`
+	FrontSnippet   string    `json:"front_snippet"`
+	BackSnippet    string    `json:"back_snippet"`
+}
+
+type SemanticResult struct {
+	Card
+	CollectionID uuid.UUID `json:"collection_id"`
+	Similarity   float64   `json:"similarity"`
+}
+
+// PdfExport is a printout of a collection the worker renders. URL is set once it is ready.
+type PdfExport struct {
+	ID             uuid.UUID `json:"id"`
+	CollectionName string    `json:"collection_name"`
+	Layout         string    `json:"layout"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+	URL            string    `json:"url,omitempty"`
+}
+
+// PromptTemplate is a saved version of a collection's card prompt, version 0 is the built-in one.
+type PromptTemplate struct {
+	Version   int32     `json:"version"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PromptPreview asks for the prompt rendered from Template, the saved one when empty.
+type PromptPreview struct {
+	Template string      `json:"template"`
+	Options  llm.Options `json:"options"`
+}
+
+// TextGeneration asks for cards from pasted Text, plain or markdown, or from a Topic alone.
+type TextGeneration struct {
+	Text          string      `json:"text"`
+	Format        string      `json:"format"`
+	Topic         string      `json:"topic"`
+	Options       llm.Options `json:"options"`
+	DuplicateMode string      `json:"duplicate_mode"`
+}
+
+// CardAssist is a hint or explanation of a card. Cached is set when it was
+// written for an earlier request about the same content of the card.
+type CardAssist struct {
+	Kind      string    `json:"kind"`
+	Text      string    `json:"text"`
+	Cached    bool      `json:"cached"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MnemonicJob is a queued run over the leeches of a collection, cards failed
+// at least MinLapses times. The worker reports on the websocket of ID.
+type MnemonicJob struct {
+	ID             uuid.UUID `json:"id"`
+	CollectionName string    `json:"collection_name"`
+	MinLapses      int       `json:"min_lapses"`
+}
+
+// MnemonicSuggestion is a mnemonic written for a card, Front and Back are rendered HTML.
+type MnemonicSuggestion struct {
+	ID        uuid.UUID `json:"id"`
+	CardID    uuid.UUID `json:"card_id"`
+	Front     string    `json:"front"`
+	Back      string    `json:"back"`
+	Kind      string    `json:"kind"`
+	Text      string    `json:"text"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatQuestion asks about the files of a collection, FileID keeps to one of them.
+// History holds the earlier turns, the client keeps the conversation.
+type ChatQuestion struct {
+	Question string            `json:"question"`
+	FileID   *uuid.UUID        `json:"file_id,omitempty"`
+	History  []llm.ChatMessage `json:"history"`
+}
+
+// ChatAnswer cites passages as [n] in Answer, Citations says where each one comes from.
+type ChatAnswer struct {
+	Answer     string         `json:"answer"`
+	AnswerHTML string         `json:"answer_html"`
+	Citations  []ChatCitation `json:"citations"`
+}
+
+type ChatCitation struct {
+	Number   int       `json:"number"`
+	ChunkID  uuid.UUID `json:"chunk_id"`
+	FileID   uuid.UUID `json:"file_id"`
+	FileName string    `json:"file_name"`
+	Page     int       `json:"page,omitempty"`
+	Excerpt  string    `json:"excerpt"`
+}
+
+// ChatCard turns a chat answer into a card, the passage of ChunkID becomes its source.
+type ChatCard struct {
+	Question string     `json:"question"`
+	Answer   string     `json:"answer"`
+	ChunkID  *uuid.UUID `json:"chunk_id,omitempty"`
+}
+
+// RewriteRequest asks for an AI rewrite of one card, Instructions are optional.
+type RewriteRequest struct {
+	Action       string `json:"action"`
+	Instructions string `json:"instructions"`
+}
+
+// Rewrite is a proposed change of a card, nothing is saved until it is
+// accepted. The first card replaces the original, others are added next to it.
+type Rewrite struct {
+	Action   string `json:"action"`
+	Original Card   `json:"original"`
+	Cards    []Card `json:"cards"`
+}
+
+// RewriteAccept saves the cards of a Rewrite, edited by the user or not.
+type RewriteAccept struct {
+	Cards []RewriteCard `json:"cards"`
+}
+
+type RewriteCard struct {
+	Front string `json:"front"`
+	Back  string `json:"back"`
+}
+
+// TableImport is a CSV or TSV upload. Columns name the header of each field,
+// or its 1-based position when the table has no header.
+type TableImport struct {
+	Format    string        `json:"format"`
+	Content   string        `json:"content"`
+	HasHeader bool          `json:"has_header"`
+	Columns   ColumnMapping `json:"columns"`
+	DryRun    bool          `json:"dry_run"`
+}
+
+// TextImport is a pasted list of terms and definitions. Separators are tab,
+// comma, semicolon, newline, dash or any custom string.
+type TextImport struct {
+	Content       string   `json:"content"`
+	TermSeparator string   `json:"term_separator"`
+	CardSeparator string   `json:"card_separator"`
+	Tags          []string `json:"tags"`
+	DryRun        bool     `json:"dry_run"`
+}
+
+type ColumnMapping struct {
+	Front string `json:"front"`
+	Back  string `json:"back"`
+	Tags  string `json:"tags"`
+	Due   string `json:"due"`
+}
+
+// ImportReport lists what an import created, or would create on a dry run.
+// Invalid rows are skipped and reported, they don't stop the import.
+type ImportReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Total   int              `json:"total"`
+	Valid   int              `json:"valid"`
+	Created int              `json:"created"`
+	Errors  []ImportRowError `json:"errors"`
+	Cards   []Card           `json:"cards,omitempty"`
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
@@ -93,6 +93,67 @@ func (s *Storage) GeneratePresignedUrl(ctx context.Context, key string, lifetime
 	return res.URL, nil
 }
 
-// func (s *Storage) ListFiles()
+// GeneratePresignedPutUrl presigns an upload that S3 only accepts with the given
+// content type and exactly size bytes.
+func (s *Storage) GeneratePresignedPutUrl(ctx context.Context, key, contentType string, size int64, lifetime int) (string, error) {
+	res, err := s.s3PresignClient.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucketName),
+		Key:           aws.String(key),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+	}, func(options *s3.PresignOptions) {
+		options.Expires = time.Duration(lifetime * int(time.Minute))
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error on creating PresignedURL: %v", err)
+	}
+	return res.URL, nil
+}
+
+func (s *Storage) GeneratePresignedGetUrl(ctx context.Context, key string, lifetime int) (string, error) {
+	res, err := s.s3PresignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	}, func(options *s3.PresignOptions) {
+		options.Expires = time.Duration(lifetime * int(time.Minute))
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error on creating PresignedURL: %v", err)
+	}
+	return res.URL, nil
+}
 
-// func (s *Storage) DeleteFIle()
+// HeadFile returns the size and content type of a stored object.
+func (s *Storage) HeadFile(ctx context.Context, key string) (int64, string, error) {
+	res, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("Couldn't head object %v:%v. Here's why: %v", s.bucketName, key, err)
+	}
+	return aws.ToInt64(res.ContentLength), aws.ToString(res.ContentType), nil
+}
+
+func (s *Storage) DeleteFile(ctx context.Context, key string) error {
+	_, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("Couldn't delete object %v:%v. Here's why: %v", s.bucketName, key, err)
+	}
+	return nil
+}
+
+// MediaKey is the object key of a card media file.
+func MediaKey(id string) string {
+	return "media/" + id
+}
+
+// ExportKey is the object key of a rendered PDF export.
+func ExportKey(id string) string {
+	return "exports/" + id + ".pdf"
+}
+
+// func (s *Storage) ListFiles()
@@ -0,0 +1,363 @@
+package workerqueue
+
+import (
+	"CueMind/internal/anki"
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"CueMind/internal/storage"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// card_schedules.state by anki card type
+var ankiStates = []string{"new", "learning", "review", "relearning"}
+
+// ankiImport holds the state of one import. Media files are uploaded once per collection.
+type ankiImport struct {
+	cfg      WorkerConfig
+	qtx      *database.Queries
+	pkg      *anki.Package
+	media    map[uuid.UUID]map[string]uuid.UUID
+	attached map[uuid.UUID]bool
+	// object keys to remove again when the import fails
+	uploaded []string
+}
+
+func handleAnkiImport(id int, msg amqp091.Delivery, cfg WorkerConfig, data Message) {
+	start := time.Now()
+
+	n, err := importAnki(context.Background(), cfg, data)
+	if err != nil {
+		failure(msg, &cfg, data.FileKey, data.FileName, fmt.Errorf("anki import: %v", err))
+		return
+	}
+
+	msg.Ack(true)
+	log.Printf("Worker %d imported %d cards. Elapsed time: %s\n", id, n, time.Since(start))
+
+	err = cfg.hub.Send(data.FileKey, fmt.Sprintf("Imported %d cards from %v", n, data.FileName))
+	if err != nil {
+		log.Printf("cannot send to the websocket : %v", err)
+	}
+}
+
+// importAnki turns the notes of an uploaded .apkg into active cards and returns how many were created.
+func importAnki(ctx context.Context, cfg WorkerConfig, data Message) (int, error) {
+	fileID, err := uuid.Parse(data.FileKey)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := cfg.storage.GetFile(ctx, data.FileKey)
+	if err != nil {
+		return 0, err
+	}
+	apkg, err := spoolFile(file)
+	file.Close()
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(apkg.Name())
+	apkg.Close()
+
+	pkg, err := anki.Open(apkg.Name())
+	if err != nil {
+		return 0, err
+	}
+	defer pkg.Close()
+
+	notes, err := pkg.Notes()
+	if err != nil {
+		return 0, err
+	}
+	cards, err := pkg.Cards()
+	if err != nil {
+		return 0, err
+	}
+	var reviews map[int64][]anki.Review
+	if data.KeepHistory {
+		reviews, err = pkg.Reviews()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := cfg.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	imp := &ankiImport{
+		cfg:      cfg,
+		qtx:      cfg.db.WithTx(tx),
+		pkg:      pkg,
+		media:    make(map[uuid.UUID]map[string]uuid.UUID),
+		attached: make(map[uuid.UUID]bool),
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			imp.removeUploads(ctx)
+		}
+	}()
+	err = lockCollection(ctx, imp.qtx, data.CollectionID)
+	if err != nil {
+		return 0, err
+	}
+
+	collections, err := imp.collections(ctx, cards, data)
+	if err != nil {
+		return 0, err
+	}
+
+	var cardIDs []uuid.UUID
+	var texts []string
+	for _, card := range cards {
+		note, ok := notes[card.NoteID]
+		if !ok {
+			continue
+		}
+		collectionID := collections[card.DeckID]
+
+		front, back, used, err := imp.cardContent(ctx, collectionID, note, card)
+		if err != nil {
+			return 0, err
+		}
+		if front == "" {
+			continue
+		}
+
+		params := database.ImportCardParams{
+			Front:        front,
+			Back:         back,
+			CollectionID: collectionID,
+			FileID:       uuid.NullUUID{UUID: fileID, Valid: true},
+			Tags:         content.NormalizeTags(note.Tags),
+		}
+		if data.KeepHistory {
+			if due := pkg.DueDate(card); !due.IsZero() {
+				params.DueDate = sql.NullTime{Time: due, Valid: true}
+			}
+		}
+		cardID, err := imp.qtx.ImportCard(ctx, params)
+		if err != nil {
+			return 0, fmt.Errorf("cannot save card: %v", err)
+		}
+
+		//a media file shared by several notes lists under the first card
+		for _, mediaID := range used {
+			if imp.attached[mediaID] {
+				continue
+			}
+			err = imp.qtx.AttachMedia(ctx, database.AttachMediaParams{CardID: uuid.NullUUID{UUID: cardID, Valid: true}, ID: mediaID})
+			if err != nil {
+				return 0, err
+			}
+			imp.attached[mediaID] = true
+		}
+
+		if data.KeepHistory {
+			err = imp.saveHistory(ctx, cardID, card, reviews[card.ID])
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		cardIDs = append(cardIDs, cardID)
+		texts = append(texts, front+"\n"+back)
+	}
+
+	err = imp.qtx.Processed(ctx, database.ProcessedParams{ID: fileID, Processed: true})
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	committed = true
+
+	embedImportedCards(ctx, cfg, cardIDs, texts)
+	return len(cardIDs), nil
+}
+
+// collections maps every deck that has cards to a collection. A package with one deck
+// fills the collection it was uploaded to, with several decks each gets its own collection.
+func (imp *ankiImport) collections(ctx context.Context, cards []anki.Card, data Message) (map[int64]uuid.UUID, error) {
+	var decks []int64
+	collections := make(map[int64]uuid.UUID)
+	for _, card := range cards {
+		if _, ok := collections[card.DeckID]; !ok {
+			collections[card.DeckID] = data.CollectionID
+			decks = append(decks, card.DeckID)
+		}
+	}
+	if len(decks) <= 1 {
+		return collections, nil
+	}
+
+	for _, deckID := range decks {
+		name := imp.pkg.Decks[deckID].Name
+		if name == "" {
+			name = data.FileName
+		}
+		id, err := imp.qtx.CreateCollection(ctx, database.CreateCollectionParams{Name: name, UserID: data.UserID})
+		if err != nil {
+			return nil, fmt.Errorf("cannot create collection for deck %v: %v", name, err)
+		}
+		collections[deckID] = id
+	}
+	return collections, nil
+}
+
+// cardContent renders a card to markdown and returns the media it uses. Sounds are
+// attached to the card, they can't be placed in the text.
+func (imp *ankiImport) cardContent(ctx context.Context, collectionID uuid.UUID, note anki.Note, card anki.Card) (string, string, []uuid.UUID, error) {
+	frontHTML, backHTML := imp.pkg.Render(note, card)
+	frontHTML, frontSounds := anki.Sounds(frontHTML)
+	backHTML, backSounds := anki.Sounds(backHTML)
+
+	var used []uuid.UUID
+	var err error
+	ref := func(name string) string {
+		if err != nil {
+			return ""
+		}
+		var id uuid.UUID
+		var ok bool
+		id, ok, err = imp.mediaID(ctx, collectionID, name)
+		if !ok {
+			return ""
+		}
+		used = append(used, id)
+		return content.MediaScheme + id.String()
+	}
+
+	front := content.FromHTML(frontHTML, ref)
+	back := content.FromHTML(backHTML, ref)
+	for _, name := range append(frontSounds, backSounds...) {
+		ref(name)
+	}
+	return front, back, used, err
+}
+
+// mediaID stores a media file of the package as collection media. ok is false for
+// files missing from the package or of a type cards can't attach.
+func (imp *ankiImport) mediaID(ctx context.Context, collectionID uuid.UUID, name string) (uuid.UUID, bool, error) {
+	if id, ok := imp.media[collectionID][name]; ok {
+		return id, true, nil
+	}
+
+	path, ok := imp.pkg.MediaPath(name)
+	if !ok {
+		//img src is sometimes url encoded
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			path, ok = imp.pkg.MediaPath(unescaped)
+		}
+	}
+	contentType := content.MediaType(name)
+	kind, supported := content.MediaKind(contentType)
+	if !ok || !supported {
+		return uuid.Nil, false, nil
+	}
+
+	id, err := imp.qtx.CreateUploadedMedia(ctx, database.CreateUploadedMediaParams{CollectionID: collectionID, ContentType: contentType, Kind: kind})
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("cannot save media %v: %v", name, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	defer f.Close()
+	key := storage.MediaKey(id.String())
+	err = imp.cfg.storage.UploadFile(ctx, key, f)
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	imp.uploaded = append(imp.uploaded, key)
+
+	if imp.media[collectionID] == nil {
+		imp.media[collectionID] = make(map[string]uuid.UUID)
+	}
+	imp.media[collectionID][name] = id
+	return id, true, nil
+}
+
+func (imp *ankiImport) saveHistory(ctx context.Context, cardID uuid.UUID, card anki.Card, reviews []anki.Review) error {
+	state := ankiStates[0]
+	if card.Type >= 0 && card.Type < len(ankiStates) {
+		state = ankiStates[card.Type]
+	}
+	ease := card.Factor
+	if ease == 0 {
+		ease = 2500
+	}
+	err := imp.qtx.UpsertCardSchedule(ctx, database.UpsertCardScheduleParams{
+		CardID:       cardID,
+		State:        state,
+		IntervalDays: int32(max(card.Interval, 0)),
+		EaseFactor:   int32(ease),
+		Reps:         int32(card.Reps),
+		Lapses:       int32(card.Lapses),
+	})
+	if err != nil {
+		return fmt.Errorf("cannot save schedule: %v", err)
+	}
+
+	for _, r := range reviews {
+		//ease 0 is a manual reschedule, not an answer
+		if r.Ease < 1 || r.Ease > 4 {
+			continue
+		}
+		err = imp.qtx.CreateCardReview(ctx, database.CreateCardReviewParams{
+			CardID:       cardID,
+			ReviewedAt:   time.UnixMilli(r.ID),
+			Rating:       int32(r.Ease),
+			IntervalDays: int32(max(r.Interval, 0)),
+			EaseFactor:   int32(r.Factor),
+			DurationMs:   int32(r.Time),
+		})
+		if err != nil {
+			return fmt.Errorf("cannot save review: %v", err)
+		}
+	}
+	return nil
+}
+
+func (imp *ankiImport) removeUploads(ctx context.Context) {
+	for _, key := range imp.uploaded {
+		err := imp.cfg.storage.DeleteFile(ctx, key)
+		if err != nil {
+			log.Printf("cannot remove media %v of failed import: %v", key, err)
+		}
+	}
+}
+
+// embedImportedCards stores embeddings for search, failures are only logged.
+func embedImportedCards(ctx context.Context, cfg WorkerConfig, cardIDs []uuid.UUID, texts []string) {
+	if len(texts) == 0 {
+		return
+	}
+	vectors, err := cfg.embedder.Embed(ctx, texts)
+	if err != nil {
+		log.Printf("cannot embed imported cards: %v", err)
+		return
+	}
+	for i := range cardIDs {
+		err = cfg.db.UpsertCardEmbedding(ctx, database.UpsertCardEmbeddingParams{CardID: cardIDs[i], Model: cfg.embedder.EmbeddingModel(), Embedding: vectors[i]})
+		if err != nil {
+			log.Printf("cannot save embedding of card %v: %v", cardIDs[i], err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package workerqueue
+
+import (
+	"CueMind/internal/llm"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// text sent to the model at once, about 6k tokens, small enough for the cards
+// of a chunk to fit the output limit
+const maxChunkChars = 24000
+
+// chunks of one file generated at the same time
+const chunkConcurrency = 3
+
+// chunk is a run of whole pages, or a section of a page too long for one chunk.
+type chunk struct {
+	firstPage int
+	lastPage  int
+	text      string
+}
+
+// pdfPages extracts the text of each page with pdftotext, which ends pages with a form feed.
+func pdfPages(ctx context.Context, pdfPath string) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "pdftotext", "-layout", pdfPath, "-").Output()
+	if err != nil {
+		return nil, fmt.Errorf("pdftotext failed: %v", err)
+	}
+	pages := strings.Split(string(out), "\f")
+	//the last page ends with a form feed too
+	if len(pages) > 0 && strings.TrimSpace(pages[len(pages)-1]) == "" {
+		pages = pages[:len(pages)-1]
+	}
+	return pages, nil
+}
+
+// splitChunks packs pages into chunks of at most maxChunkChars. Each page starts
+// with a [Page N] marker so the model can still tell where cards come from.
+func splitChunks(pages []string) []chunk {
+	var chunks []chunk
+	var cur chunk
+	flush := func() {
+		if strings.TrimSpace(cur.text) != "" {
+			chunks = append(chunks, cur)
+		}
+		cur = chunk{}
+	}
+
+	for i, page := range pages {
+		page = strings.TrimSpace(page)
+		if page == "" {
+			continue
+		}
+		n := i + 1
+		text := fmt.Sprintf("[Page %d]\n%s\n\n", n, page)
+		if len(cur.text)+len(text) > maxChunkChars {
+			flush()
+		}
+		if len(text) > maxChunkChars {
+			for _, section := range splitSections(page, n) {
+				chunks = append(chunks, chunk{firstPage: n, lastPage: n, text: section})
+			}
+			continue
+		}
+		if cur.text == "" {
+			cur.firstPage = n
+		}
+		cur.lastPage = n
+		cur.text += text
+	}
+	flush()
+	return chunks
+}
+
+// splitSections cuts a long page at paragraph breaks, a paragraph longer than a
+// chunk is cut where it gets too long.
+func splitSections(page string, n int) []string {
+	header := fmt.Sprintf("[Page %d]\n", n)
+	//a section is the header, paragraphs and the break after each one
+	limit := maxChunkChars - len(header) - 2
+	var sections []string
+	cur := header
+	for _, paragraph := range strings.Split(page, "\n\n") {
+		if strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		for len(paragraph) > limit {
+			if cur != header {
+				sections = append(sections, cur)
+				cur = header
+			}
+			cut := limit
+			for cut > 0 && !utf8.RuneStart(paragraph[cut]) {
+				cut--
+			}
+			sections = append(sections, header+paragraph[:cut]+"\n\n")
+			paragraph = paragraph[cut:]
+		}
+		if cur != header && len(cur)+len(paragraph)+2 > maxChunkChars {
+			sections = append(sections, cur)
+			cur = header
+		}
+		cur += paragraph + "\n\n"
+	}
+	if cur != header {
+		sections = append(sections, cur)
+	}
+	return sections
+}
+
+// chunkFigures returns the figures found on the pages of c.
+func chunkFigures(c chunk, figures []llm.Figure) []llm.Figure {
+	var out []llm.Figure
+	for _, f := range figures {
+		if f.Page >= c.firstPage && f.Page <= c.lastPage {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// generateChunks asks the model for the cards of each chunk, a few at a time, and
+// reports every finished chunk on the websocket. Cards come back in page order;
+// duplicates between chunks are left to dedupCards. A failed chunk only costs its
+// own cards, the job fails when none succeeds.
+func generateChunks(ctx context.Context, cfg WorkerConfig, data Message, chunks []chunk, figures []llm.Figure) ([]llm.Card, error) {
+	total := 0
+	for _, c := range chunks {
+		total += len(c.text)
+	}
+	results := make([][]llm.Card, len(chunks))
+	errs := make([]error, len(chunks))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	sem := make(chan struct{}, chunkConcurrency)
+	for i := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			c := chunks[i]
+			resp, err := cfg.llm.GenerateCardsFromText(ctx, c.text, chunkFigures(c, figures), chunkOptions(data.Options, c, total))
+			if err != nil {
+				errs[i] = err
+				log.Printf("cannot generate cards for pages %d-%d of %v: %v", c.firstPage, c.lastPage, data.FileName, err)
+			} else {
+				results[i] = resp.Cards
+			}
+
+			mu.Lock()
+			done++
+			msg := fmt.Sprintf("Generated cards for pages %d-%d of %v", c.firstPage, c.lastPage, data.FileName)
+			if err != nil {
+				msg = fmt.Sprintf("Could not generate cards for pages %d-%d of %v", c.firstPage, c.lastPage, data.FileName)
+			}
+			cfg.hub.Progress(data.FileKey, done, len(chunks), msg)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	var cards []llm.Card
+	failed := 0
+	for i := range chunks {
+		if errs[i] != nil {
+			failed++
+			continue
+		}
+		cards = append(cards, results[i]...)
+	}
+	if failed == len(chunks) {
+		return nil, fmt.Errorf("all %d chunks failed, last error: %v", failed, errs[len(errs)-1])
+	}
+	return cards, nil
+}
+
+// chunkOptions shares a card count out between chunks by the length of their text.
+func chunkOptions(opts llm.Options, c chunk, total int) llm.Options {
+	if opts.Count > 0 && total > 0 {
+		opts.Count = max(1, (opts.Count*len(c.text)+total-1)/total)
+	}
+	return opts
+}
@@ -0,0 +1,110 @@
+package workerqueue
+
+import (
+	"CueMind/internal/llm"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitChunks(t *testing.T) {
+	half := strings.Repeat("a", maxChunkChars/2)
+	paragraphs := strings.Repeat(strings.Repeat("b", 1000)+"\n\n", 40)
+	tests := []struct {
+		name  string
+		pages []string
+		want  [][2]int
+	}{
+		{name: "pages packed", pages: []string{"one", "two", " \n", "four"}, want: [][2]int{{1, 4}}},
+		{name: "full chunk flushed", pages: []string{half, half, "three"}, want: [][2]int{{1, 1}, {2, 3}}},
+		{name: "oversized page split", pages: []string{"one", paragraphs, "three"}, want: [][2]int{{1, 1}, {2, 2}, {2, 2}, {3, 3}}},
+		{name: "empty", pages: []string{"", "\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := splitChunks(tt.pages)
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.want))
+			}
+			for i, c := range chunks {
+				if c.firstPage != tt.want[i][0] || c.lastPage != tt.want[i][1] {
+					t.Errorf("chunk %d covers pages %d-%d, want %d-%d", i, c.firstPage, c.lastPage, tt.want[i][0], tt.want[i][1])
+				}
+				if len(c.text) > maxChunkChars {
+					t.Errorf("chunk %d is %d bytes, over %d", i, len(c.text), maxChunkChars)
+				}
+				if !strings.HasPrefix(c.text, fmt.Sprintf("[Page %d]\n", c.firstPage)) {
+					t.Errorf("chunk %d doesn't start with its page marker: %.20q", i, c.text)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitSections(t *testing.T) {
+	header := "[Page 7]\n"
+	limit := maxChunkChars - len(header) - 2
+	tests := []struct {
+		name     string
+		page     string
+		sections int
+	}{
+		{name: "paragraphs fit", page: "one\n\ntwo", sections: 1},
+		{name: "paragraph filling a section", page: strings.Repeat("a", limit), sections: 1},
+		{name: "paragraph one byte too long", page: strings.Repeat("a", limit+1), sections: 2},
+		{name: "long paragraph after a short one", page: "short\n\n" + strings.Repeat("a", 2*limit+5), sections: 4},
+		{name: "multibyte cut points", page: strings.Repeat("é", maxChunkChars), sections: 3},
+		{name: "blank paragraphs skipped", page: "\n\n \n\none\n\n\n\n", sections: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections := splitSections(tt.page, 7)
+			if len(sections) != tt.sections {
+				t.Fatalf("got %d sections, want %d", len(sections), tt.sections)
+			}
+			var text strings.Builder
+			for i, s := range sections {
+				if len(s) > maxChunkChars {
+					t.Errorf("section %d is %d bytes, over %d", i, len(s), maxChunkChars)
+				}
+				if !utf8.ValidString(s) {
+					t.Errorf("section %d was cut inside a character", i)
+				}
+				body, ok := strings.CutPrefix(s, header)
+				if !ok || strings.TrimSpace(body) == "" {
+					t.Errorf("section %d has no text after its header: %.20q", i, s)
+				}
+				text.WriteString(strings.ReplaceAll(body, "\n", ""))
+			}
+			if want := strings.Join(strings.Fields(tt.page), ""); text.String() != want {
+				t.Error("sections don't add up to the page text")
+			}
+		})
+	}
+}
+
+func TestChunkOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		chars int
+		total int
+		want  int
+	}{
+		{name: "share by length", count: 10, chars: 500, total: 1000, want: 5},
+		{name: "rounded up", count: 3, chars: 100, total: 1000, want: 1},
+		{name: "at least one", count: 1, chars: 1, total: 1000, want: 1},
+		{name: "no count", count: 0, chars: 500, total: 1000, want: 0},
+		{name: "no text", count: 4, chars: 0, total: 0, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := chunk{text: strings.Repeat("x", tt.chars)}
+			got := chunkOptions(llm.Options{Count: tt.count}, c, tt.total)
+			if got.Count != tt.want {
+				t.Errorf("got count %d, want %d", got.Count, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package workerqueue
+
+import (
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// DuplicateMode decides what happens to generated cards that already exist in the collection.
+type DuplicateMode string
+
+const (
+	DuplicateSkip  DuplicateMode = "skip"
+	DuplicateMerge DuplicateMode = "merge"
+	DuplicateFlag  DuplicateMode = "flag"
+)
+
+// cards whose embeddings are at least this close are treated as the same card
+const nearDuplicateThreshold = 0.92
+
+// a new card this close to an existing one asks about a concept the collection
+// already covers, it's dropped when generation sticks to gaps
+const coveredThreshold = 0.85
+
+func ValidDuplicateMode(mode string) bool {
+	switch DuplicateMode(mode) {
+	case "", DuplicateSkip, DuplicateMerge, DuplicateFlag:
+		return true
+	}
+	return false
+}
+
+type pendingCard struct {
+	card      llm.Card
+	embedding []float32
+	// existing card this one duplicates, only set in flag mode
+	duplicateOf uuid.NullUUID
+}
+
+type dedupResult struct {
+	insert []pendingCard
+	// existing card ID -> the card with the new answer merged into its back,
+	// saved as a draft that replaces the card's back once accepted
+	merge   map[uuid.UUID]*existingCard
+	skipped int
+	// dropped for asking about something the collection already covers
+	covered int
+}
+
+type existingCard struct {
+	id        uuid.UUID
+	front     string
+	back      string
+	embedding []float32
+}
+
+// dedupCards compares freshly generated cards against the collection and against each other.
+// Only active cards count, drafts still waiting for review and rejected cards don't.
+// Exact matches are found by a fingerprint of the normalized front, near matches by embedding
+// similarity when vectors are available. Duplicates inside the new batch are always dropped.
+// With gaps set, cards merely close to an existing one are dropped as well.
+func dedupCards(ctx context.Context, cfg WorkerConfig, collectionID uuid.UUID, cards []llm.Card, vectors [][]float32, mode DuplicateMode, gaps bool) (*dedupResult, error) {
+	dbCards, err := cfg.db.ListCollectionCardTexts(ctx, collectionID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load existing cards: %v", err)
+	}
+	existing := make([]*existingCard, len(dbCards))
+	byID := make(map[uuid.UUID]*existingCard, len(dbCards))
+	for i := range dbCards {
+		c := &existingCard{id: dbCards[i].ID, front: dbCards[i].Front, back: dbCards[i].Back}
+		existing[i] = c
+		byID[c.id] = c
+	}
+
+	if vectors != nil {
+		rows, err := cfg.db.ListCollectionCardEmbeddings(ctx, database.ListCollectionCardEmbeddingsParams{CollectionID: collectionID, Model: cfg.embedder.EmbeddingModel()})
+		if err != nil {
+			return nil, fmt.Errorf("cannot load existing embeddings: %v", err)
+		}
+		for i := range rows {
+			if c, ok := byID[rows[i].CardID]; ok {
+				c.embedding = rows[i].Embedding
+			}
+		}
+	}
+	return dedup(existing, cards, vectors, mode, gaps), nil
+}
+
+// dedup sorts cards into the ones to insert, merge or drop against existing,
+// vectors[i] is the embedding of cards[i] when vectors isn't nil.
+func dedup(existing []*existingCard, cards []llm.Card, vectors [][]float32, mode DuplicateMode, gaps bool) *dedupResult {
+	if mode == "" {
+		mode = DuplicateSkip
+	}
+	byFingerprint := make(map[string]*existingCard, len(existing))
+	for _, c := range existing {
+		byFingerprint[cardFingerprint(c.front)] = c
+	}
+
+	result := &dedupResult{merge: make(map[uuid.UUID]*existingCard)}
+	seen := make(map[string]bool, len(cards))
+	for i := range cards {
+		pending := pendingCard{card: cards[i]}
+		if vectors != nil {
+			pending.embedding = vectors[i]
+		}
+
+		fingerprint := cardFingerprint(cards[i].Front)
+		if seen[fingerprint] || nearPending(pending.embedding, result.insert) {
+			result.skipped++
+			continue
+		}
+		seen[fingerprint] = true
+
+		match := byFingerprint[fingerprint]
+		if match == nil {
+			match = nearExisting(pending.embedding, existing, nearDuplicateThreshold)
+		}
+		if match == nil && gaps && nearExisting(pending.embedding, existing, coveredThreshold) != nil {
+			result.covered++
+			continue
+		}
+		if match == nil {
+			result.insert = append(result.insert, pending)
+			continue
+		}
+
+		switch mode {
+		case DuplicateFlag:
+			pending.duplicateOf = uuid.NullUUID{UUID: match.id, Valid: true}
+			result.insert = append(result.insert, pending)
+		case DuplicateMerge:
+			if strings.Contains(normalizeCardText(match.back), normalizeCardText(cards[i].Back)) {
+				result.skipped++
+				continue
+			}
+			match.back = match.back + "\n\n" + cards[i].Back
+			result.merge[match.id] = match
+		default:
+			result.skipped++
+		}
+	}
+	return result
+}
+
+func nearExisting(embedding []float32, existing []*existingCard, threshold float64) *existingCard {
+	if embedding == nil {
+		return nil
+	}
+	var best *existingCard
+	bestScore := threshold
+	for _, c := range existing {
+		if c.embedding == nil {
+			continue
+		}
+		if score := llm.CosineSimilarity(embedding, c.embedding); score >= bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+func nearPending(embedding []float32, pending []pendingCard) bool {
+	if embedding == nil {
+		return false
+	}
+	for i := range pending {
+		if pending[i].embedding != nil && llm.CosineSimilarity(embedding, pending[i].embedding) >= nearDuplicateThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+func cardFingerprint(front string) string {
+	sum := sha1.Sum([]byte(normalizeCardText(front)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeCardText lowercases text, drops punctuation and collapses whitespace.
+func normalizeCardText(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
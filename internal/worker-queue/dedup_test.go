@@ -0,0 +1,170 @@
+package workerqueue
+
+import (
+	"CueMind/internal/llm"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDedup(t *testing.T) {
+	embedder := llm.FakeEmbedder{Dimensions: 256}
+	embed := func(cards []llm.Card) [][]float32 {
+		texts := make([]string, len(cards))
+		for i := range cards {
+			texts[i] = cards[i].Front + "\n" + cards[i].Back
+		}
+		vectors, err := embedder.Embed(context.Background(), texts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return vectors
+	}
+
+	stored := []llm.Card{
+		{Front: "What does the mitochondria produce?", Back: "ATP"},
+		{Front: "Where does glycolysis happen?", Back: "In the cytoplasm"},
+	}
+	existingCards := func() []*existingCard {
+		vectors := embed(stored)
+		existing := make([]*existingCard, len(stored))
+		for i := range stored {
+			existing[i] = &existingCard{id: uuid.New(), front: stored[i].Front, back: stored[i].Back, embedding: vectors[i]}
+		}
+		return existing
+	}
+
+	tests := []struct {
+		name     string
+		cards    []llm.Card
+		embed    bool
+		mode     DuplicateMode
+		gaps     bool
+		inserted []string
+		skipped  int
+		merged   int
+		flagged  int
+		covered  int
+	}{
+		{
+			name: "new card kept",
+			cards: []llm.Card{
+				{Front: "What is the Krebs cycle?", Back: "A series of reactions releasing energy"},
+			},
+			embed:    true,
+			inserted: []string{"What is the Krebs cycle?"},
+		},
+		{
+			name: "same front skipped without embeddings",
+			cards: []llm.Card{
+				{Front: "what does the Mitochondria produce", Back: "Energy"},
+			},
+			skipped: 1,
+		},
+		{
+			name: "near duplicate skipped",
+			cards: []llm.Card{
+				{Front: "What does the mitochondria produce?", Back: "ATP."},
+			},
+			embed:   true,
+			skipped: 1,
+		},
+		{
+			name: "duplicates inside the batch",
+			cards: []llm.Card{
+				{Front: "Name the Krebs cycle product", Back: "NADH"},
+				{Front: "name the krebs cycle product!", Back: "NADH"},
+			},
+			embed:    true,
+			inserted: []string{"Name the Krebs cycle product"},
+			skipped:  1,
+		},
+		{
+			name: "merge adds the new answer",
+			cards: []llm.Card{
+				{Front: "Where does glycolysis happen?", Back: "Outside the mitochondria"},
+			},
+			mode:   DuplicateMerge,
+			merged: 1,
+		},
+		{
+			name: "merge skips an answer already there",
+			cards: []llm.Card{
+				{Front: "Where does glycolysis happen?", Back: "in the cytoplasm."},
+			},
+			mode:    DuplicateMerge,
+			skipped: 1,
+		},
+		{
+			name: "flag keeps the card",
+			cards: []llm.Card{
+				{Front: "Where does glycolysis happen?", Back: "Cytoplasm"},
+			},
+			mode:     DuplicateFlag,
+			inserted: []string{"Where does glycolysis happen?"},
+			flagged:  1,
+		},
+		{
+			name: "covered concept dropped with gaps",
+			cards: []llm.Card{
+				{Front: "What does mitochondria produce?", Back: "ATP"},
+			},
+			embed:   true,
+			gaps:    true,
+			covered: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vectors [][]float32
+			if tt.embed {
+				vectors = embed(tt.cards)
+			}
+			result := dedup(existingCards(), tt.cards, vectors, tt.mode, tt.gaps)
+
+			if len(result.insert) != len(tt.inserted) {
+				t.Fatalf("inserted %d cards, want %d", len(result.insert), len(tt.inserted))
+			}
+			flagged := 0
+			for i, front := range tt.inserted {
+				if result.insert[i].card.Front != front {
+					t.Errorf("inserted %q, want %q", result.insert[i].card.Front, front)
+				}
+				if result.insert[i].duplicateOf.Valid {
+					flagged++
+				}
+			}
+			if result.skipped != tt.skipped || len(result.merge) != tt.merged || flagged != tt.flagged || result.covered != tt.covered {
+				t.Errorf("skipped %d merged %d flagged %d covered %d, want %d %d %d %d",
+					result.skipped, len(result.merge), flagged, result.covered,
+					tt.skipped, tt.merged, tt.flagged, tt.covered)
+			}
+		})
+	}
+}
+
+func TestDedupMergedBack(t *testing.T) {
+	id := uuid.New()
+	existing := []*existingCard{{id: id, front: "Capital of France?", back: "Paris"}}
+	result := dedup(existing, []llm.Card{{Front: "capital of france", Back: "Paris, on the Seine"}}, nil, DuplicateMerge, false)
+	if got, want := result.merge[id].back, "Paris\n\nParis, on the Seine"; got != want {
+		t.Errorf("merged back %q, want %q", got, want)
+	}
+}
+
+func TestReadyMessage(t *testing.T) {
+	tests := []struct {
+		covered int
+		want    string
+	}{
+		{covered: 0, want: "Your cards from notes.pdf are ready for review"},
+		{covered: 2, want: "Your cards from notes.pdf are ready for review, 2 cards were left out because the collection already covers them"},
+	}
+	for _, tt := range tests {
+		got := readyMessage("notes.pdf", &dedupResult{covered: tt.covered})
+		if got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package workerqueue
+
+import (
+	"CueMind/internal/database"
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// handleEmbedCards refreshes the embeddings of cards created or edited through
+// the API, requests only queue them so they don't wait on the embedding API.
+func handleEmbedCards(id int, msg amqp091.Delivery, cfg WorkerConfig, data Message) {
+	err := embedStoredCards(context.Background(), cfg, data.CardIDs)
+	if err != nil {
+		msg.Nack(false, false)
+		log.Printf("Worker %d cannot embed %d cards: %v", id, len(data.CardIDs), err)
+		return
+	}
+	msg.Ack(true)
+}
+
+// embedStoredCards embeds the current text of the cards, deleted ones are
+// skipped. When embedding fails the old vectors are dropped, they no longer
+// match the cards.
+func embedStoredCards(ctx context.Context, cfg WorkerConfig, cardIDs []uuid.UUID) error {
+	rows, err := cfg.db.ListCardTexts(ctx, cardIDs)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	texts := make([]string, len(rows))
+	for i := range rows {
+		texts[i] = rows[i].Front + "\n" + rows[i].Back
+	}
+
+	vectors, err := cfg.embedder.Embed(ctx, texts)
+	if err == nil && len(vectors) != len(rows) {
+		err = fmt.Errorf("expected %d embeddings, got %d", len(rows), len(vectors))
+	}
+	if err != nil {
+		for i := range rows {
+			cfg.db.DeleteCardEmbedding(ctx, rows[i].ID)
+		}
+		return err
+	}
+
+	for i := range rows {
+		err = cfg.db.UpsertCardEmbedding(ctx, database.UpsertCardEmbeddingParams{CardID: rows[i].ID, Model: cfg.embedder.EmbeddingModel(), Embedding: vectors[i]})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package workerqueue
+
+import (
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"CueMind/internal/storage"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// images smaller than this are mostly icons, bullets and logos
+const minFigureSize = 8 * 1024
+
+// cap on figures sent to the model for one file
+const maxFigures = 20
+
+// figures go inline in the request, larger images are skipped and the ones
+// of a file stop at the total
+const (
+	maxFigureSize   = 1 << 20
+	maxFiguresTotal = 8 << 20
+)
+
+type figure struct {
+	// set once storeFigures saved it as media
+	mediaID uuid.UUID
+	llm.Figure
+}
+
+// pdfimages -p names files <root>-<page>-<n>.png
+var figureName = regexp.MustCompile(`-(\d+)-\d+\.png$`)
+
+// extractFigures pulls the images embedded in a PDF out with pdfimages, numbered
+// in page order for the prompt. They are only stored once cards use them.
+func extractFigures(pdfPath string) ([]figure, error) {
+	dir, err := os.MkdirTemp("", "figures-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.Command("pdfimages", "-png", "-p", pdfPath, filepath.Join(dir, "img"))
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("pdfimages failed: %v", err)
+	}
+
+	//glob results are sorted, zero padded pages keep them in page order
+	names, err := filepath.Glob(filepath.Join(dir, "*.png"))
+	if err != nil {
+		return nil, err
+	}
+
+	var figures []figure
+	total := 0
+	for _, name := range names {
+		if len(figures) == maxFigures {
+			break
+		}
+		match := figureName.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		page, _ := strconv.Atoi(match[1])
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < minFigureSize || len(data) > maxFigureSize {
+			continue
+		}
+		if total+len(data) > maxFiguresTotal {
+			break
+		}
+		total += len(data)
+
+		figures = append(figures, figure{
+			Figure: llm.Figure{Number: len(figures) + 1, Page: page, Format: "png", Data: data},
+		})
+	}
+	return figures, nil
+}
+
+// storeFigures saves the figures the cards show as media of the collection,
+// the others are dropped with the job.
+func storeFigures(ctx context.Context, cfg WorkerConfig, collectionID, fileID uuid.UUID, figures []figure, cards []pendingCard) error {
+	for i := range cards {
+		fig := cardFigure(cards[i].card, figures)
+		if fig == nil || fig.mediaID != uuid.Nil {
+			continue
+		}
+		mediaID, err := cfg.db.CreateFileFigure(ctx, database.CreateFileFigureParams{
+			CollectionID: collectionID,
+			FileID:       uuid.NullUUID{UUID: fileID, Valid: true},
+			Page:         sourcePage(fig.Page),
+			ContentType:  "image/png",
+		})
+		if err != nil {
+			return fmt.Errorf("cannot save figure: %v", err)
+		}
+		err = cfg.storage.UploadFile(ctx, storage.MediaKey(mediaID.String()), bytes.NewReader(fig.Data))
+		if err != nil {
+			cfg.db.DeleteMedia(ctx, database.DeleteMediaParams{ID: mediaID, CollectionID: collectionID})
+			return err
+		}
+		fig.mediaID = mediaID
+	}
+	return nil
+}
+
+// removeFigures deletes what storeFigures saved, for when the cards weren't.
+func removeFigures(ctx context.Context, cfg WorkerConfig, collectionID uuid.UUID, figures []figure) {
+	for i := range figures {
+		if figures[i].mediaID == uuid.Nil {
+			continue
+		}
+		cfg.db.DeleteMedia(ctx, database.DeleteMediaParams{ID: figures[i].mediaID, CollectionID: collectionID})
+		err := cfg.storage.DeleteFile(ctx, storage.MediaKey(figures[i].mediaID.String()))
+		if err != nil {
+			log.Printf("cannot delete figure %v from storage: %v", figures[i].mediaID, err)
+		}
+		figures[i].mediaID = uuid.Nil
+	}
+}
+
+func llmFigures(figures []figure) []llm.Figure {
+	out := make([]llm.Figure, len(figures))
+	for i := range figures {
+		out[i] = figures[i].Figure
+	}
+	return out
+}
+
+// cardFigure returns the figure a generated card refers to, nil when it refers to none.
+func cardFigure(card llm.Card, figures []figure) *figure {
+	if card.Figure < 1 || card.Figure > len(figures) {
+		return nil
+	}
+	return &figures[card.Figure-1]
+}
+
+// spoolFile copies r into a temp file, rewound for reading.
+func spoolFile(r io.Reader) (*os.File, error) {
+	tmp, err := os.CreateTemp("", "cuemind-*")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(tmp, r)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	return tmp, nil
+}
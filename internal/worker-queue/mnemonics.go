@@ -0,0 +1,108 @@
+package workerqueue
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// leeches looked at in one job, the worst ones first
+const maxMnemonicCards = 200
+
+// handleMnemonics writes mnemonics for the leeches of a collection. They are
+// saved as suggestions the user approves or rejects, cards aren't changed.
+// FileKey only names the websocket waiting on the job.
+func handleMnemonics(id int, msg amqp091.Delivery, cfg WorkerConfig, data Message) {
+	start := time.Now()
+	ctx := context.Background()
+
+	n, err := suggestMnemonics(ctx, cfg, data)
+	if err != nil {
+		msg.Nack(false, false)
+		log.Printf("Worker %d failed to write mnemonics for %v: %v", id, data.FileName, err)
+		cfg.hub.Send(data.FileKey, fmt.Sprintf("There was an error on writing mnemonics for %v", data.FileName))
+		return
+	}
+
+	msg.Ack(true)
+	log.Printf("Worker %d wrote %d mnemonics. Elapsed time: %s\n", id, n, time.Since(start))
+
+	err = cfg.hub.Send(data.FileKey, fmt.Sprintf("%d mnemonics for %v are ready for review", n, data.FileName))
+	if err != nil {
+		log.Printf("cannot send to the websocket : %v", err)
+	}
+}
+
+// suggestMnemonics asks for the mnemonics of the leeches a batch at a time and
+// saves them. A failed batch is skipped, the job fails when all of them do.
+func suggestMnemonics(ctx context.Context, cfg WorkerConfig, data Message) (int, error) {
+	rows, err := cfg.db.ListMnemonicCandidates(ctx, database.ListMnemonicCandidatesParams{
+		CollectionID: data.CollectionID,
+		MinLapses:    int32(data.MinLapses),
+		MaxCards:     maxMnemonicCards,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	batches := (len(rows) + llm.MnemonicBatch - 1) / llm.MnemonicBatch
+	saved, failed := 0, 0
+	var lastErr error
+	for b := 0; b < batches; b++ {
+		batch := rows[b*llm.MnemonicBatch : min((b+1)*llm.MnemonicBatch, len(rows))]
+		cards := make([]llm.MnemonicCard, len(batch))
+		for i, row := range batch {
+			cards[i] = llm.MnemonicCard{
+				Front: content.PlainText(row.Front, row.ContentFormat),
+				Back:  content.PlainText(row.Back, row.ContentFormat),
+			}
+		}
+
+		mnemonics, err := cfg.llm.Mnemonics(ctx, cards)
+		if err != nil {
+			failed++
+			lastErr = err
+			log.Printf("cannot write mnemonics for batch %d of %v: %v", b+1, data.FileName, err)
+		}
+		n, err := saveMnemonics(ctx, cfg, batch, mnemonics)
+		if err != nil {
+			return saved, fmt.Errorf("error on saving mnemonics: %v", err)
+		}
+		saved += n
+		cfg.hub.Progress(data.FileKey, b+1, batches, fmt.Sprintf("Wrote mnemonics for %d of %d cards", min((b+1)*llm.MnemonicBatch, len(rows)), len(rows)))
+	}
+	if batches > 0 && failed == batches {
+		return 0, fmt.Errorf("all %d batches failed, last error: %v", failed, lastErr)
+	}
+	return saved, nil
+}
+
+// saveMnemonics stores the suggestions of a batch together. A card that got a
+// pending suggestion meanwhile, from another job, keeps that one.
+func saveMnemonics(ctx context.Context, cfg WorkerConfig, batch []database.ListMnemonicCandidatesRow, mnemonics []llm.Mnemonic) (int, error) {
+	if len(mnemonics) == 0 {
+		return 0, nil
+	}
+	tx, err := cfg.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.db.WithTx(tx)
+	saved := 0
+	for _, m := range mnemonics {
+		n, err := qtx.CreateCardMnemonic(ctx, database.CreateCardMnemonicParams{CardID: batch[m.Card-1].ID, Kind: m.Kind, Body: m.Text})
+		if err != nil {
+			return 0, err
+		}
+		saved += int(n)
+	}
+	return saved, tx.Commit()
+}
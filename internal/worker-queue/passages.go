@@ -0,0 +1,211 @@
+package workerqueue
+
+import (
+	"CueMind/internal/database"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// passages the chat retrieves are a few paragraphs, small enough that several
+// fit a prompt and specific enough to cite
+const maxPassageChars = 1500
+
+type passage struct {
+	// 0 for text without pages
+	page int
+	text string
+}
+
+// splitPassages packs the paragraphs of each page into passages of at most
+// maxPassageChars characters. Passages don't cross pages so a citation names
+// one page. Pages are numbered from 1 when paged is set.
+func splitPassages(pages []string, paged bool) []passage {
+	var passages []passage
+	for i, page := range pages {
+		n := 0
+		if paged {
+			n = i + 1
+		}
+		cur, curChars := "", 0
+		for _, paragraph := range strings.Split(strings.ReplaceAll(page, "\r\n", "\n"), "\n\n") {
+			paragraph = strings.TrimSpace(paragraph)
+			if paragraph == "" {
+				continue
+			}
+			chars := utf8.RuneCountInString(paragraph)
+			if cur != "" && curChars+chars+2 > maxPassageChars {
+				passages = append(passages, passage{page: n, text: cur})
+				cur, curChars = "", 0
+			}
+			if chars > maxPassageChars {
+				runes := []rune(paragraph)
+				for len(runes) > maxPassageChars {
+					passages = append(passages, passage{page: n, text: string(runes[:maxPassageChars])})
+					runes = runes[maxPassageChars:]
+				}
+				paragraph, chars = string(runes), len(runes)
+			}
+			if cur != "" {
+				cur += "\n\n"
+				curChars += 2
+			}
+			cur += paragraph
+			curChars += chars
+		}
+		if cur != "" {
+			passages = append(passages, passage{page: n, text: cur})
+		}
+	}
+	return passages
+}
+
+// storePassages keeps the text of a file for the chat, replacing what an
+// earlier run stored. Without embeddings nothing is stored; the cards of the
+// file don't depend on it, so failures are only logged.
+func storePassages(ctx context.Context, cfg WorkerConfig, fileID uuid.UUID, pages []string, paged bool) {
+	err := savePassages(ctx, cfg, fileID, splitPassages(pages, paged))
+	if err != nil {
+		log.Printf("cannot store the text of file %v: %v", fileID, err)
+	}
+}
+
+func savePassages(ctx context.Context, cfg WorkerConfig, fileID uuid.UUID, passages []passage) error {
+	if len(passages) == 0 {
+		return nil
+	}
+	texts := make([]string, len(passages))
+	for i := range passages {
+		texts[i] = passages[i].text
+	}
+	vectors, err := cfg.embedder.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	if len(vectors) != len(passages) {
+		return fmt.Errorf("expected %d embeddings, got %d", len(passages), len(vectors))
+	}
+
+	tx, err := cfg.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := cfg.db.WithTx(tx)
+	err = qtx.DeleteFileChunks(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	for i, p := range passages {
+		err = qtx.CreateFileChunk(ctx, database.CreateFileChunkParams{
+			FileID:    fileID,
+			Position:  int32(i),
+			Page:      int32(p.page),
+			Body:      p.text,
+			Model:     cfg.embedder.EmbeddingModel(),
+			Embedding: vectors[i],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// files looked at per query of the passage backfill
+const backfillBatch = 50
+
+// handleBackfillPassages stores the text of processed files that have none,
+// like the files processed before the chat existed. Each file is read once,
+// ones whose text can't be read stay without passages.
+func handleBackfillPassages(id int, msg amqp091.Delivery, cfg WorkerConfig) {
+	ctx := context.Background()
+	after, stored := uuid.Nil, 0
+	for {
+		files, err := cfg.db.ListFilesWithoutPassages(ctx, database.ListFilesWithoutPassagesParams{After: after, MaxFiles: backfillBatch})
+		if err != nil {
+			msg.Nack(false, false)
+			log.Printf("Worker %d stopped the passage backfill: %v", id, err)
+			return
+		}
+		for _, file := range files {
+			after = file.ID
+			//recorded first, a file that brings the worker down isn't read on every start
+			err = cfg.db.CreateFilePassageAttempt(ctx, file.ID)
+			if err != nil {
+				log.Printf("Worker %d cannot record the backfill of file %v: %v", id, file.ID, err)
+				continue
+			}
+			err = reprocessPassages(ctx, cfg, file.ID, file.Format.String)
+			if err != nil {
+				log.Printf("Worker %d cannot backfill the text of file %v: %v", id, file.ID, err)
+				continue
+			}
+			stored++
+		}
+		if len(files) < backfillBatch {
+			break
+		}
+	}
+	msg.Ack(true)
+	if stored > 0 {
+		log.Printf("Worker %d stored the text of %d older files", id, stored)
+	}
+}
+
+// reprocessPassages reads a stored file again and keeps its text for the chat.
+// Uploads other than PDFs are converted first, like a generation job does.
+func reprocessPassages(ctx context.Context, cfg WorkerConfig, fileID uuid.UUID, format string) error {
+	if format == "text" || format == "markdown" {
+		text, err := cfg.db.GetFileText(ctx, fileID)
+		if err != nil {
+			return err
+		}
+		return savePassages(ctx, cfg, fileID, splitPassages([]string{text}, false))
+	}
+
+	file, err := cfg.storage.GetFile(ctx, fileID.String())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	pdf, err := spoolFile(file)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(pdf.Name())
+	defer pdf.Close()
+
+	path := pdf.Name()
+	if format != "pdf" {
+		curDir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		converted, err := convertToPdf(path, curDir+"/tmp/pdf/")
+		if err != nil {
+			return err
+		}
+		//the converted file is unlinked already, pdftotext needs a path
+		convertedCopy, err := spoolFile(converted)
+		converted.Close()
+		if err != nil {
+			return err
+		}
+		defer os.Remove(convertedCopy.Name())
+		defer convertedCopy.Close()
+		path = convertedCopy.Name()
+	}
+	pages, err := pdfPages(ctx, path)
+	if err != nil {
+		return err
+	}
+	return savePassages(ctx, cfg, fileID, splitPassages(pages, true))
+}
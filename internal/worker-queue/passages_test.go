@@ -0,0 +1,47 @@
+package workerqueue
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitPassages(t *testing.T) {
+	long := strings.Repeat("ü", maxPassageChars+10)
+	tests := []struct {
+		name  string
+		pages []string
+		paged bool
+		want  []passage
+	}{
+		{
+			name:  "paragraphs packed per page",
+			pages: []string{"one\r\n\r\ntwo", "three"},
+			paged: true,
+			want:  []passage{{page: 1, text: "one\n\ntwo"}, {page: 2, text: "three"}},
+		},
+		{
+			name:  "multibyte text counted in characters",
+			pages: []string{strings.Repeat("é", maxPassageChars-10) + "\n\n" + "ßß"},
+			want:  []passage{{text: strings.Repeat("é", maxPassageChars-10) + "\n\nßß"}},
+		},
+		{
+			name:  "long paragraph cut",
+			pages: []string{long},
+			want:  []passage{{text: long[:2*maxPassageChars]}, {text: strings.Repeat("ü", 10)}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitPassages(tt.pages, tt.paged)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d passages, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("passage %d is %d chars on page %d, want %d on %d", i, utf8.RuneCountInString(got[i].text), got[i].page, utf8.RuneCountInString(tt.want[i].text), tt.want[i].page)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package workerqueue
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"CueMind/internal/printout"
+	"CueMind/internal/storage"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// minutes the download link sent over the websocket stays valid
+const exportUrlLifetime = 60
+
+func handlePdfExport(id int, msg amqp091.Delivery, cfg WorkerConfig, data Message) {
+	start := time.Now()
+	ctx := context.Background()
+
+	exportID, err := uuid.Parse(data.FileKey)
+	if err != nil {
+		msg.Nack(false, false)
+		log.Printf("Worker %d cannot parse export id: %v", id, err)
+		return
+	}
+
+	url, err := renderPdfExport(ctx, cfg, exportID, data)
+	status := "ready"
+	if err != nil {
+		status = "failed"
+	}
+	if serr := cfg.db.SetPdfExportStatus(ctx, database.SetPdfExportStatusParams{Status: status, ID: exportID}); serr != nil {
+		log.Printf("Worker %d cannot save export status: %v", id, serr)
+	}
+
+	if err != nil {
+		msg.Nack(false, false)
+		log.Printf("Worker %d failed to export %v: %v", id, data.FileName, err)
+		cfg.hub.Send(data.FileKey, fmt.Sprintf("There was an error on exporting %v", data.FileName))
+		return
+	}
+
+	msg.Ack(true)
+	log.Printf("Worker %d exported %v. Elapsed time: %s\n", id, data.FileName, time.Since(start))
+
+	err = cfg.hub.Send(data.FileKey, fmt.Sprintf("Your printout of %v is ready: %v", data.FileName, url))
+	if err != nil {
+		log.Printf("cannot send to the websocket : %v", err)
+	}
+}
+
+// renderPdfExport prints the active cards of the collection, uploads the PDF
+// and returns a download link for it.
+func renderPdfExport(ctx context.Context, cfg WorkerConfig, exportID uuid.UUID, data Message) (string, error) {
+	rows, err := cfg.db.ListCardsForExport(ctx, data.CollectionID)
+	if err != nil {
+		return "", err
+	}
+	cards := make([]printout.Card, len(rows))
+	for i, row := range rows {
+		cards[i] = printout.Card{
+			Front: content.PlainText(row.Front, row.ContentFormat),
+			Back:  content.PlainText(row.Back, row.ContentFormat),
+		}
+	}
+
+	tmp, err := os.CreateTemp("", "cuemind-print-*.pdf")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	err = printout.Write(tmp, data.FileName, cards, data.Layout)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	key := storage.ExportKey(exportID.String())
+	err = cfg.storage.UploadFile(ctx, key, tmp)
+	if err != nil {
+		return "", err
+	}
+	return cfg.storage.GeneratePresignedGetUrl(ctx, key, exportUrlLifetime)
+}
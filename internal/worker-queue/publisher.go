@@ -1,6 +1,7 @@
 package workerqueue
 
 import (
+	"CueMind/internal/llm"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -11,12 +12,42 @@ import (
 
 const ExchangeName = "main"
 
+// message types, an empty type is a card generation job
+const (
+	MessageGenerateCards = "generate_cards"
+	MessageAnkiImport    = "anki_import"
+	MessageVaultImport   = "vault_import"
+	MessagePdfExport     = "pdf_export"
+	MessageGenerateText  = "generate_text"
+	MessageMnemonics     = "mnemonics"
+	MessageEmbedCards    = "embed_cards"
+	// stores the text of files processed before the chat, published on start
+	MessageBackfillPassages = "backfill_passages"
+)
+
 type Message struct {
-	UserID       uuid.UUID `json:"userID"`
-	CollectionID uuid.UUID `json:"collectionID"`
-	FileName     string    `json:"filename"`
-	FileKey      string    `json:"file_key"`
-	Format       string    `json:"format"`
+	Type          string    `json:"type"`
+	UserID        uuid.UUID `json:"userID"`
+	CollectionID  uuid.UUID `json:"collectionID"`
+	FileName      string    `json:"filename"`
+	FileKey       string    `json:"file_key"`
+	Format        string    `json:"format"`
+	DuplicateMode string    `json:"duplicate_mode"`
+	// what cards the LLM writes for card generation and LLM vault imports
+	Options llm.Options `json:"options"`
+	// anki imports keep intervals, due dates and the review log
+	KeepHistory bool `json:"keep_history"`
+	// vault imports read cards from the note syntax or have the LLM write them
+	VaultMode string `json:"vault_mode"`
+	// pdf exports: the printout layout, FileKey is the export id
+	Layout string `json:"layout"`
+	// text generations: the topic to write cards about, empty for pasted text,
+	// which is stored with the file
+	Topic string `json:"topic"`
+	// mnemonic jobs: lapses that make a card a leech
+	MinLapses int `json:"min_lapses"`
+	// embedding jobs: the cards to embed again
+	CardIDs []uuid.UUID `json:"card_ids"`
 }
 
 type Queue struct {
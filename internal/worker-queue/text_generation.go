@@ -0,0 +1,71 @@
+package workerqueue
+
+import (
+	"CueMind/internal/llm"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// handleTextGeneration writes cards for pasted text or a bare topic. There is no
+// upload, FileKey is the file entry made for the request, the text is stored
+// with it, so the cards land in the draft inbox like the ones of an uploaded file.
+func handleTextGeneration(id int, msg amqp091.Delivery, cfg WorkerConfig, data Message) {
+	start := time.Now()
+	ctx := context.Background()
+
+	fileID, err := uuid.Parse(data.FileKey)
+	if err != nil {
+		failure(msg, &cfg, data.FileKey, data.FileName, fmt.Errorf("cannot parse file id: %v", err))
+		return
+	}
+
+	opts, err := collectionPrompt(ctx, cfg, data.CollectionID, data.Options)
+	if err != nil {
+		failure(msg, &cfg, data.FileKey, data.FileName, err)
+		return
+	}
+
+	var flashcards *llm.FlashCardResponse
+	if data.Topic != "" {
+		flashcards, err = cfg.llm.GenerateCardsFromTopic(ctx, data.Topic, opts)
+	} else {
+		var text string
+		text, err = cfg.db.GetFileText(ctx, fileID)
+		if err != nil {
+			failure(msg, &cfg, data.FileKey, data.FileName, fmt.Errorf("cannot get the pasted text: %v", err))
+			return
+		}
+		storePassages(ctx, cfg, fileID, []string{text}, false)
+		flashcards, err = cfg.llm.GenerateCardsFromText(ctx, text, nil, opts)
+	}
+	if err != nil {
+		failure(msg, &cfg, data.FileKey, data.FileName, err)
+		return
+	}
+	//pasted text has no pages, a page number from the model would point nowhere
+	for i := range flashcards.Cards {
+		flashcards.Cards[i].PageStart, flashcards.Cards[i].PageEnd = 0, 0
+		if data.Topic != "" {
+			flashcards.Cards[i].Source = ""
+		}
+	}
+
+	deduped, err := saveGeneratedCards(ctx, cfg, id, data, fileID, flashcards.Cards, nil)
+	if err != nil {
+		failure(msg, &cfg, data.FileKey, data.FileName, err)
+		return
+	}
+
+	msg.Ack(true)
+	log.Printf("Worker %d generated cards from text. Elapsed time: %s\n", id, time.Since(start))
+
+	err = cfg.hub.Send(data.FileKey, readyMessage(data.FileName, deduped))
+	if err != nil {
+		log.Printf("cannot send to the websocket : %v", err)
+	}
+}
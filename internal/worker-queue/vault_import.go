@@ -0,0 +1,399 @@
+package workerqueue
+
+import (
+	"CueMind/internal/content"
+	"CueMind/internal/database"
+	"CueMind/internal/llm"
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// VaultMode decides how cards come out of the notes of a markdown vault.
+type VaultMode string
+
+const (
+	// cards written in the notes as Q:/A: blocks or term :: definition lines
+	VaultSyntax VaultMode = "syntax"
+	// each changed note goes through the LLM, the cards land in the draft inbox
+	// and only replace drafts, reviewed cards stay as they are
+	VaultLLM VaultMode = "llm"
+)
+
+// notes bigger than this are no study notes, attachments saved as .md mostly
+const maxNoteSize = 1 << 20
+
+func ValidVaultMode(mode string) bool {
+	switch VaultMode(mode) {
+	case "", VaultSyntax, VaultLLM:
+		return true
+	}
+	return false
+}
+
+type vaultNote struct {
+	key  string
+	hash string
+	tags []string
+	body string
+	// cards of the note in order, noteAnchor identifies them on re-import
+	cards []content.Pair
+}
+
+type vaultSync struct {
+	created, updated, removed int
+	// notes left for the next import, their cards couldn't be generated
+	skipped int
+	cardIDs []uuid.UUID
+	texts   []string
+}
+
+func handleVaultImport(id int, msg amqp091.Delivery, cfg WorkerConfig, data Message) {
+	start := time.Now()
+
+	result, err := importVault(context.Background(), cfg, data)
+	if err != nil {
+		failure(msg, &cfg, data.FileKey, data.FileName, fmt.Errorf("vault import: %v", err))
+		return
+	}
+
+	msg.Ack(true)
+	log.Printf("Worker %d synced vault: %d created, %d updated, %d removed, %d notes skipped. Elapsed time: %s\n", id, result.created, result.updated, result.removed, result.skipped, time.Since(start))
+
+	report := fmt.Sprintf("Synced %v: %d cards created, %d updated, %d removed", data.FileName, result.created, result.updated, result.removed)
+	if result.skipped > 0 {
+		report += fmt.Sprintf(", %d notes failed and are retried next time", result.skipped)
+	}
+	err = cfg.hub.Send(data.FileKey, report)
+	if err != nil {
+		log.Printf("cannot send to the websocket : %v", err)
+	}
+}
+
+// importVault syncs the collection with the notes of an uploaded vault zip. Notes
+// are matched by their frontmatter id or path, only changed notes are read again
+// and the cards of notes gone from the vault are moved to the trash.
+func importVault(ctx context.Context, cfg WorkerConfig, data Message) (*vaultSync, error) {
+	fileID, err := uuid.Parse(data.FileKey)
+	if err != nil {
+		return nil, err
+	}
+	mode := VaultMode(data.VaultMode)
+	if mode == "" {
+		mode = VaultSyntax
+	}
+
+	file, err := cfg.storage.GetFile(ctx, data.FileKey)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := spoolFile(file)
+	file.Close()
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(archive.Name())
+	archive.Close()
+
+	notes, err := readVault(archive.Name(), mode)
+	if err != nil {
+		return nil, err
+	}
+
+	known, err := cfg.db.ListVaultNotes(ctx, data.CollectionID)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string, len(known))
+	for _, n := range known {
+		hashes[n.NoteKey] = n.ContentHash
+	}
+
+	opts := data.Options
+	//a changed note is read again in full, its own earlier cards are no gap to avoid
+	opts.IncludeKnown = true
+	if mode == VaultLLM {
+		opts, err = collectionPrompt(ctx, cfg, data.CollectionID, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//the model is slow, get all cards before the transaction
+	result := &vaultSync{}
+	var changed []vaultNote
+	for _, note := range notes {
+		if hashes[note.key] == note.hash {
+			continue
+		}
+		if mode == VaultLLM {
+			note.cards, err = generateNoteCards(ctx, cfg, note, opts)
+			if err != nil {
+				//its hash isn't saved, so the next import tries the note again
+				log.Printf("skipping vault note %v: cannot generate cards: %v", note.key, err)
+				result.skipped++
+				continue
+			}
+		}
+		changed = append(changed, note)
+	}
+
+	tx, err := cfg.sql.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+	err = lockCollection(ctx, qtx, data.CollectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := "active"
+	if mode == VaultLLM {
+		status = "draft"
+	}
+	batch := database.InsertCardsParams{CollectionID: data.CollectionID, Status: status, ContentFormat: content.FormatMarkdown}
+	for _, note := range changed {
+		err = syncNote(ctx, qtx, data.CollectionID, fileID, note, mode == VaultLLM, &batch, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(batch.Ids) > 0 {
+		err = qtx.InsertCards(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("cannot save cards: %v", err)
+		}
+	}
+
+	present := make(map[string]bool, len(notes))
+	for _, note := range notes {
+		present[note.key] = true
+	}
+	for _, n := range known {
+		if present[n.NoteKey] {
+			continue
+		}
+		removed, err := qtx.DeleteNoteCards(ctx, database.DeleteNoteCardsParams{
+			CollectionID: data.CollectionID,
+			NoteKey:      sql.NullString{String: n.NoteKey, Valid: true},
+			DraftsOnly:   mode == VaultLLM,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.removed += int(removed)
+		err = qtx.DeleteVaultNote(ctx, database.DeleteVaultNoteParams{CollectionID: data.CollectionID, NoteKey: n.NoteKey})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = qtx.Processed(ctx, database.ProcessedParams{ID: fileID, Processed: true})
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	embedImportedCards(ctx, cfg, result.cardIDs, result.texts)
+	return result, nil
+}
+
+// syncNote matches the cards of a changed note to the ones saved last time by
+// their anchor: changed ones are updated in place, keeping their schedule, new
+// ones are added to batch and the ones no longer in the note go to the trash.
+// With draftsOnly, for generated cards, only drafts are replaced: cards the user
+// already reviewed are left as they are.
+func syncNote(ctx context.Context, qtx *database.Queries, collectionID, fileID uuid.UUID, note vaultNote, draftsOnly bool, batch *database.InsertCardsParams, result *vaultSync) error {
+	noteKey := sql.NullString{String: note.key, Valid: true}
+	existing, err := qtx.ListNoteCards(ctx, database.ListNoteCardsParams{CollectionID: collectionID, NoteKey: noteKey})
+	if err != nil {
+		return err
+	}
+	//cards with the same front share an anchor, they are matched in order
+	byAnchor := make(map[string][]database.ListNoteCardsRow, len(existing))
+	for _, c := range existing {
+		byAnchor[c.NoteAnchor.String] = append(byAnchor[c.NoteAnchor.String], c)
+	}
+
+	tags := content.NormalizeTags(note.tags)
+	keep := make([]uuid.UUID, 0, len(note.cards))
+	for i, pair := range note.cards {
+		anchor := noteAnchor(pair)
+		ordinal := sql.NullInt32{Int32: int32(i), Valid: true}
+		matchedBy := anchor
+		//a card given a block id since the last import was saved by its front
+		if len(byAnchor[anchor]) == 0 && pair.BlockID != "" {
+			matchedBy = frontAnchor(pair.Term)
+		}
+		matches := byAnchor[matchedBy]
+		if len(matches) == 0 {
+			id := batch.Add(database.CardRow{
+				Front:       pair.Term,
+				Back:        pair.Definition,
+				FileID:      uuid.NullUUID{UUID: fileID, Valid: true},
+				Tags:        tags,
+				NoteKey:     noteKey,
+				NoteOrdinal: ordinal,
+				NoteAnchor:  sql.NullString{String: anchor, Valid: true},
+			})
+			result.created++
+			result.cardIDs = append(result.cardIDs, id)
+			result.texts = append(result.texts, pair.Term+"\n"+pair.Definition)
+			continue
+		}
+		old := matches[0]
+		byAnchor[matchedBy] = matches[1:]
+		keep = append(keep, old.ID)
+		if draftsOnly && old.Status != "draft" {
+			continue
+		}
+		if old.Front == pair.Term && old.Back == pair.Definition && slices.Equal(old.Tags, tags) && old.NoteOrdinal == ordinal && old.NoteAnchor.String == anchor {
+			continue
+		}
+		err = qtx.UpdateNoteCard(ctx, database.UpdateNoteCardParams{
+			Front:       pair.Term,
+			Back:        pair.Definition,
+			Tags:        tags,
+			NoteOrdinal: ordinal,
+			NoteAnchor:  sql.NullString{String: anchor, Valid: true},
+			ID:          old.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot update card: %v", err)
+		}
+		//moving in the note or getting an id leaves the text and its embedding alone
+		if old.Front == pair.Term && old.Back == pair.Definition {
+			continue
+		}
+		result.updated++
+		result.cardIDs = append(result.cardIDs, old.ID)
+		result.texts = append(result.texts, pair.Term+"\n"+pair.Definition)
+	}
+
+	removed, err := qtx.DeleteNoteCards(ctx, database.DeleteNoteCardsParams{CollectionID: collectionID, NoteKey: noteKey, KeepIds: keep, DraftsOnly: draftsOnly})
+	if err != nil {
+		return err
+	}
+	result.removed += int(removed)
+
+	return qtx.UpsertVaultNote(ctx, database.UpsertVaultNoteParams{
+		CollectionID: collectionID,
+		NoteKey:      note.key,
+		ContentHash:  note.hash,
+		FileID:       uuid.NullUUID{UUID: fileID, Valid: true},
+	})
+}
+
+// noteAnchor identifies a card within its note: the ^block id it was given in
+// Obsidian, else a hash of its front, so editing the back updates the card and
+// editing the front of a card without an id replaces it.
+func noteAnchor(pair content.Pair) string {
+	if pair.BlockID != "" {
+		return "^" + pair.BlockID
+	}
+	return frontAnchor(pair.Term)
+}
+
+// frontAnchor must match the one the note_anchors migration gave existing cards.
+func frontAnchor(front string) string {
+	sum := sha256.Sum256([]byte(front))
+	return "h:" + hex.EncodeToString(sum[:])[:16]
+}
+
+// readVault reads the markdown notes of a vault zip. The Obsidian config folder
+// and other hidden folders are skipped.
+func readVault(zipPath string, mode VaultMode) ([]vaultNote, error) {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid zip: %v", err)
+	}
+	defer archive.Close()
+
+	var notes []vaultNote
+	seen := make(map[string]bool)
+	for _, f := range archive.File {
+		name := path.Clean(strings.ReplaceAll(f.Name, `\`, "/"))
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(name), ".md") || hiddenPath(name) {
+			continue
+		}
+		if f.UncompressedSize64 > maxNoteSize {
+			log.Printf("skipping vault note %v: too big", name)
+			continue
+		}
+
+		src, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %v: %v", name, err)
+		}
+		raw, err := io.ReadAll(src)
+		src.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %v: %v", name, err)
+		}
+
+		parsed := content.ParseNote(string(raw))
+		key := parsed.ID
+		if key == "" {
+			key = strings.TrimSuffix(name, path.Ext(name))
+		}
+		//two notes claiming one id would overwrite each other's cards
+		if seen[key] {
+			log.Printf("skipping vault note %v: id %v already used", name, key)
+			continue
+		}
+		seen[key] = true
+
+		//the mode is part of the hash so switching it reads every note again
+		sum := sha256.Sum256([]byte(string(mode) + "\x00" + string(raw)))
+		note := vaultNote{key: key, hash: hex.EncodeToString(sum[:]), tags: parsed.Tags, body: parsed.Body}
+		if mode == VaultSyntax {
+			note.cards = content.NoteCards(parsed.Body)
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+func generateNoteCards(ctx context.Context, cfg WorkerConfig, note vaultNote, opts llm.Options) ([]content.Pair, error) {
+	if strings.TrimSpace(note.body) == "" {
+		return nil, nil
+	}
+	//a count is asked for the whole upload, it means nothing for one note
+	opts.Count = 0
+	flashcards, err := cfg.llm.GenerateCardsFromText(ctx, note.body, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	pairs := make([]content.Pair, 0, len(flashcards.Cards))
+	for i, card := range flashcards.Cards {
+		pairs = append(pairs, content.Pair{Entry: i + 1, Term: card.Front, Definition: card.Back})
+	}
+	return pairs, nil
+}
+
+func hiddenPath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
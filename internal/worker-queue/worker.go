@@ -1,6 +1,7 @@
 package workerqueue
 
 import (
+	"CueMind/internal/content"
 	"CueMind/internal/database"
 	"CueMind/internal/llm"
 	"CueMind/internal/storage"
@@ -8,12 +9,14 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,20 +24,21 @@ import (
 )
 
 type WorkerConfig struct {
-	sql     *sql.DB
-	db      *database.Queries
-	llm     *llm.LLMService
-	storage *storage.Storage
-	queue   *amqp091.Connection
-	hub     *ws.WSConnHub
+	sql      *sql.DB
+	db       *database.Queries
+	llm      llm.CardGenerator
+	embedder llm.Embedder
+	storage  *storage.Storage
+	queue    *amqp091.Connection
+	hub      *ws.WSConnHub
 }
 
-func NewWorkerConf(sql *sql.DB, db *database.Queries, llm *llm.LLMService, str *storage.Storage, queueUrl string, hub *ws.WSConnHub) *WorkerConfig {
+func NewWorkerConf(sql *sql.DB, db *database.Queries, llm llm.CardGenerator, embedder llm.Embedder, str *storage.Storage, queueUrl string, hub *ws.WSConnHub) *WorkerConfig {
 	conn, err := amqp091.Dial(queueUrl)
 	if err != nil {
 		log.Fatalf("ERROR | Cannot start WorkerConf")
 	}
-	return &WorkerConfig{db: db, llm: llm, storage: str, queue: conn, sql: sql, hub: hub}
+	return &WorkerConfig{db: db, llm: llm, embedder: embedder, storage: str, queue: conn, sql: sql, hub: hub}
 }
 
 func StartWorkers(cfg WorkerConfig, n int) {
@@ -107,6 +111,35 @@ func startSingleWorker(id int, cfg WorkerConfig) error {
 			continue
 		}
 
+		if messageData.Type == MessageAnkiImport {
+			handleAnkiImport(id, msg, cfg, messageData)
+			continue
+		}
+		if messageData.Type == MessageVaultImport {
+			handleVaultImport(id, msg, cfg, messageData)
+			continue
+		}
+		if messageData.Type == MessagePdfExport {
+			handlePdfExport(id, msg, cfg, messageData)
+			continue
+		}
+		if messageData.Type == MessageGenerateText {
+			handleTextGeneration(id, msg, cfg, messageData)
+			continue
+		}
+		if messageData.Type == MessageMnemonics {
+			handleMnemonics(id, msg, cfg, messageData)
+			continue
+		}
+		if messageData.Type == MessageEmbedCards {
+			handleEmbedCards(id, msg, cfg, messageData)
+			continue
+		}
+		if messageData.Type == MessageBackfillPassages {
+			handleBackfillPassages(id, msg, cfg)
+			continue
+		}
+
 		//Get file from the Storage
 		ctx := context.Background()
 		file, err := cfg.storage.GetFile(ctx, messageData.FileKey)
@@ -158,34 +191,39 @@ func startSingleWorker(id int, cfg WorkerConfig) error {
 
 		}
 
-		//Send file to the LLm
-
-		flashcards, err := cfg.llm.GenerateCardsFromFile(ctx, file)
+		fileID, err := uuid.Parse(messageData.FileKey)
 		if err != nil {
 			failure(msg, &cfg, messageData.FileKey, messageData.FileName, fmt.Errorf("ERROR: Worker cannot Parse file ID :%v \n", err))
 
 			continue
 		}
 
-		// Save in the DB
-		//iterating then inserting.
-		//TO-DO : later implement batch insert
-		err = insertCardsToDB(flashcards.Cards, messageData.CollectionID, cfg)
+		//pdfimages needs the pdf on disk
+		pdfCopy, err := spoolFile(file)
 		if err != nil {
-			failure(msg, &cfg, messageData.FileKey, messageData.FileName, fmt.Errorf("ERROR: Worker cannot Parse file ID :%v \n", err))
+			failure(msg, &cfg, messageData.FileKey, messageData.FileName, err)
 
 			continue
 		}
+		//cards still get generated without figures
+		figures, err := extractFigures(pdfCopy.Name())
+		if err != nil {
+			log.Printf("Worker %d cannot extract figures: %v", id, err)
+		}
 
-		fileID, err := uuid.Parse(messageData.FileKey)
+		//Send file to the LLm
+		cards, err := generateCards(ctx, cfg, messageData, fileID, pdfCopy, llmFigures(figures))
+		pdfCopy.Close()
+		os.Remove(pdfCopy.Name())
 		if err != nil {
 			failure(msg, &cfg, messageData.FileKey, messageData.FileName, fmt.Errorf("ERROR: Worker cannot Parse file ID :%v \n", err))
 
 			continue
 		}
-		err = cfg.db.Processed(ctx, database.ProcessedParams{ID: fileID, Processed: true})
+
+		deduped, err := saveGeneratedCards(ctx, cfg, id, messageData, fileID, cards, figures)
 		if err != nil {
-			failure(msg, &cfg, messageData.FileKey, messageData.FileName, fmt.Errorf("ERROR: Worker cannot Parse file ID :%v \n", err))
+			failure(msg, &cfg, messageData.FileKey, messageData.FileName, err)
 
 			continue
 		}
@@ -194,7 +232,7 @@ func startSingleWorker(id int, cfg WorkerConfig) error {
 		elapsed := time.Since(start)
 		log.Printf("Worker %d finished job. Elapsed time: %s\n", id, elapsed)
 
-		err = cfg.hub.Delete(fileID.String(), messageData.FileName, true)
+		err = cfg.hub.Send(fileID.String(), readyMessage(messageData.FileName, deduped))
 		if err != nil {
 			log.Printf("cannot send to the websocket : %v", err)
 		}
@@ -204,30 +242,215 @@ func startSingleWorker(id int, cfg WorkerConfig) error {
 
 }
 
-func insertCardsToDB(cards []llm.Card, collectionID uuid.UUID, cfg WorkerConfig) error {
+// saveGeneratedCards stores the cards the model wrote for a file as drafts and
+// marks the file processed. The result says which cards were left out.
+func saveGeneratedCards(ctx context.Context, cfg WorkerConfig, id int, data Message, fileID uuid.UUID, cards []llm.Card, figures []figure) (*dedupResult, error) {
+	//embed before saving, the vectors are also used to spot near-duplicates.
+	//embeddings only feed search, a failure here shouldn't fail the job
+	vectors, err := embedCards(ctx, cfg, cards)
+	if err != nil {
+		log.Printf("Worker %d cannot embed cards: %v", id, err)
+	}
+
+	deduped, err := dedupCards(ctx, cfg, data.CollectionID, cards, vectors, DuplicateMode(data.DuplicateMode), !data.Options.IncludeKnown)
+	if err != nil {
+		return nil, err
+	}
+	if deduped.skipped > 0 || len(deduped.merge) > 0 || deduped.covered > 0 {
+		log.Printf("Worker %d: %d duplicate cards skipped, %d proposed as merges, %d already covered", id, deduped.skipped, len(deduped.merge), deduped.covered)
+	}
+
+	err = storeFigures(ctx, cfg, data.CollectionID, fileID, figures, deduped.insert)
+	if err != nil {
+		removeFigures(ctx, cfg, data.CollectionID, figures)
+		return nil, err
+	}
+	// Save in the DB as drafts, the user accepts them from the inbox
+	err = insertCardsToDB(deduped, figures, data.CollectionID, fileID, cfg)
+	if err != nil {
+		removeFigures(ctx, cfg, data.CollectionID, figures)
+		return nil, err
+	}
+	err = cfg.db.Processed(ctx, database.ProcessedParams{ID: fileID, Processed: true})
+	if err != nil {
+		return nil, err
+	}
+	return deduped, nil
+}
+
+// readyMessage tells the user the drafts of a file are waiting, and how many
+// cards were dropped for asking about what the collection already covers.
+func readyMessage(fileName string, deduped *dedupResult) string {
+	msg := fmt.Sprintf("Your cards from %v are ready for review", fileName)
+	if deduped.covered > 0 {
+		msg += fmt.Sprintf(", %d cards were left out because the collection already covers them", deduped.covered)
+	}
+	return msg
+}
+
+// generateCards sends a short file to the model as it is. Longer ones are split
+// into chunks of their text so the cards of each fit in one response. Files
+// without a text layer, like scans, always go whole. The text is also kept for
+// the chat.
+func generateCards(ctx context.Context, cfg WorkerConfig, data Message, fileID uuid.UUID, pdf *os.File, figures []llm.Figure) ([]llm.Card, error) {
+	var err error
+	data.Options, err = collectionPrompt(ctx, cfg, data.CollectionID, data.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := pdfPages(ctx, pdf.Name())
+	if err != nil {
+		log.Printf("cannot read the text of %v, sending it whole: %v", data.FileName, err)
+	}
+	storePassages(ctx, cfg, fileID, pages, true)
+	chunks := splitChunks(pages)
+	if len(chunks) > 1 {
+		return generateChunks(ctx, cfg, data, chunks, figures)
+	}
+
+	flashcards, err := cfg.llm.GenerateCardsFromFile(ctx, pdf, figures, data.Options)
+	if err != nil {
+		return nil, err
+	}
+	return flashcards.Cards, nil
+}
+
+// collectionPrompt adds the collection's own prompt template to opts, and the
+// cards it already has so the model can stick to what they don't cover.
+func collectionPrompt(ctx context.Context, cfg WorkerConfig, collectionID uuid.UUID, opts llm.Options) (llm.Options, error) {
+	tmpl, err := cfg.db.GetLatestPromptTemplate(ctx, collectionID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return opts, fmt.Errorf("cannot load prompt template: %v", err)
+	default:
+		opts.Template = tmpl.Body
+	}
+
+	if !opts.IncludeKnown || strings.Contains(opts.Template, "ExistingCards") {
+		opts.ExistingCards, err = cfg.db.ListRecentCardFronts(ctx, database.ListRecentCardFrontsParams{CollectionID: collectionID, Limit: llm.MaxExistingCards})
+		if err != nil {
+			return opts, fmt.Errorf("cannot load existing cards: %v", err)
+		}
+	}
+	return opts, nil
+}
+
+func insertCardsToDB(deduped *dedupResult, figures []figure, collectionID, fileID uuid.UUID, cfg WorkerConfig) error {
 	ctx := context.Background()
 
+	//all cards go in one statement, media and embeddings follow by card id
+	batch := database.InsertCardsParams{CollectionID: collectionID, Status: "draft", ContentFormat: content.FormatMarkdown}
+	cardIDs := make([]uuid.UUID, len(deduped.insert))
+	mergeIDs := make([]uuid.UUID, 0, len(deduped.merge))
+	for i := range deduped.insert {
+		card := deduped.insert[i]
+		front := card.card.Front
+		if fig := cardFigure(card.card, figures); fig != nil {
+			front = fmt.Sprintf("%s\n\n![Figure %d](%s%s)", front, fig.Number, content.MediaScheme, fig.mediaID)
+		}
+		cardIDs[i] = batch.Add(database.CardRow{
+			Front:       front,
+			Back:        card.card.Back,
+			FileID:      uuid.NullUUID{UUID: fileID, Valid: true},
+			DuplicateOf: card.duplicateOf,
+
+			SourcePageStart: sourcePage(card.card.PageStart),
+			SourcePageEnd:   sourcePage(max(card.card.PageEnd, card.card.PageStart)),
+			SourceExcerpt:   sourceExcerpt(card.card.Source),
+		})
+	}
+	//merges wait for review like any draft, the existing card is only changed on accept
+	for cardID, merged := range deduped.merge {
+		mergeIDs = append(mergeIDs, batch.Add(database.CardRow{
+			Front:       merged.front,
+			Back:        merged.back,
+			FileID:      uuid.NullUUID{UUID: fileID, Valid: true},
+			DuplicateOf: uuid.NullUUID{UUID: cardID, Valid: true},
+		}))
+	}
+
 	tx, err := cfg.sql.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
 	qtx := cfg.db.WithTx(tx)
-	for i := range cards {
-		front := cards[i].Front
-		back := cards[i].Back
-		_, err = qtx.CreateCard(ctx, database.CreateCardParams{
-			Front:        front,
-			Back:         back,
-			CollectionID: collectionID,
-		})
+	err = lockCollection(ctx, qtx, collectionID)
+	if err != nil {
+		return err
+	}
+	if len(cardIDs)+len(mergeIDs) > 0 {
+		err = qtx.InsertCards(ctx, batch)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
 	}
+	if len(mergeIDs) > 0 {
+		err = qtx.CreateMergeDrafts(ctx, mergeIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := range deduped.insert {
+		card := deduped.insert[i]
+		if fig := cardFigure(card.card, figures); fig != nil {
+			err = qtx.AttachMedia(ctx, database.AttachMediaParams{CardID: uuid.NullUUID{UUID: cardIDs[i], Valid: true}, ID: fig.mediaID})
+			if err != nil {
+				return err
+			}
+		}
+		if card.embedding != nil {
+			err = qtx.UpsertCardEmbedding(ctx, database.UpsertCardEmbeddingParams{
+				CardID:    cardIDs[i],
+				Model:     cfg.embedder.EmbeddingModel(),
+				Embedding: card.embedding,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return tx.Commit()
+}
+
+var errCollectionDeleted = errors.New("the collection was deleted")
+
+// lockCollection keeps the collection from being deleted until tx ends, cards
+// saved into a deleted one would be left behind when it is purged.
+func lockCollection(ctx context.Context, qtx *database.Queries, collectionID uuid.UUID) error {
+	_, err := qtx.LockLiveCollection(ctx, collectionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errCollectionDeleted
+	}
+	return err
+}
+
+func sourcePage(page int) sql.NullInt32 {
+	return sql.NullInt32{Int32: int32(page), Valid: page > 0}
+}
 
+// the model sometimes quotes whole paragraphs, keep the excerpt short
+const maxExcerptLength = 500
+
+func sourceExcerpt(excerpt string) sql.NullString {
+	excerpt = strings.TrimSpace(excerpt)
+	if runes := []rune(excerpt); len(runes) > maxExcerptLength {
+		excerpt = string(runes[:maxExcerptLength]) + "…"
+	}
+	return sql.NullString{String: excerpt, Valid: excerpt != ""}
+}
+
+func embedCards(ctx context.Context, cfg WorkerConfig, cards []llm.Card) ([][]float32, error) {
+	texts := make([]string, len(cards))
+	for i := range cards {
+		texts[i] = cards[i].Front + "\n" + cards[i].Back
+	}
+	return cfg.embedder.Embed(ctx, texts)
 }
 
 func failure(msg amqp091.Delivery, cfg *WorkerConfig, fileID, fileName string, err error) {
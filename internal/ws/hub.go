@@ -3,44 +3,96 @@ package ws
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
 
+// a client that stops reading can't hold a worker for longer than this
+const writeWait = 10 * time.Second
+
 type WSConnHub struct {
 	mu    sync.RWMutex
-	conns map[string]*websocket.Conn
+	conns map[string]*hubConn
+}
+
+// hubConn is a connection with its writer lock, workers report in parallel and
+// a connection takes one writer at a time.
+type hubConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
 }
 
 func New() *WSConnHub {
-	return &WSConnHub{conns: make(map[string]*websocket.Conn)}
+	return &WSConnHub{conns: make(map[string]*hubConn)}
 }
 
 func (ws *WSConnHub) Register(fileID string, con *websocket.Conn) {
 	ws.mu.Lock()
-	ws.conns[fileID] = con
+	ws.conns[fileID] = &hubConn{conn: con}
 	ws.mu.Unlock()
 }
 
 func (ws *WSConnHub) Delete(fileID string, filename string, success bool) error {
+	var msg string
+	if !success {
+		msg = fmt.Sprintf("There was error on crearing cards from file:%v ", filename)
+	} else {
+		msg = fmt.Sprintf("Your cards from %v are ready for review", filename)
+	}
+	return ws.Send(fileID, msg)
+}
+
+// Send writes msg to the connection waiting on fileID and closes it.
+func (ws *WSConnHub) Send(fileID string, msg string) error {
 	ws.mu.Lock()
-	conn := ws.conns[fileID]
+	c := ws.conns[fileID]
 	delete(ws.conns, fileID)
 	ws.mu.Unlock()
 
-	if conn == nil {
+	if c == nil {
 		return fmt.Errorf("There is no such connection")
 	}
-	var msg string
-	if !success {
-		msg = fmt.Sprintf("There was error on crearing cards from file:%v ", filename)
-	} else {
-		msg = fmt.Sprintf("Your cards from %v are ready", filename)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.write(map[string]string{"message": msg})
+	if err != nil {
+		c.conn.Close()
+		return err
+	}
+	return c.conn.Close()
+}
+
+// Progress writes an update on a running job to the connection waiting on fileID,
+// which stays open for the final message.
+func (ws *WSConnHub) Progress(fileID string, done, total int, msg string) error {
+	ws.mu.RLock()
+	c := ws.conns[fileID]
+	ws.mu.RUnlock()
+
+	if c == nil {
+		return fmt.Errorf("There is no such connection")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.write(map[string]any{"message": msg, "done": done, "total": total})
+	if err != nil {
+		ws.mu.Lock()
+		//Send may have taken it already
+		if ws.conns[fileID] == c {
+			delete(ws.conns, fileID)
+		}
+		ws.mu.Unlock()
+		c.conn.Close()
 	}
-	err := conn.WriteJSON(map[string]string{"message": msg})
+	return err
+}
+
+// write sends v as JSON, the caller holds c.mu.
+func (c *hubConn) write(v any) error {
+	err := c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 	if err != nil {
-		conn.Close()
 		return err
 	}
-	return conn.Close()
+	return c.conn.WriteJSON(v)
 }
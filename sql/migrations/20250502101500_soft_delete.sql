@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE collections ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE cards ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE files ADD COLUMN deleted_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE files DROP COLUMN deleted_at;
+ALTER TABLE cards DROP COLUMN deleted_at;
+ALTER TABLE collections DROP COLUMN deleted_at;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE cards ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX cards_search_idx ON cards USING GIN (
+    (setweight(to_tsvector('english', front), 'A') || setweight(to_tsvector('english', back), 'B'))
+);
+CREATE INDEX cards_tags_idx ON cards USING GIN (tags);
+
+-- +goose Down
+DROP INDEX cards_tags_idx;
+DROP INDEX cards_search_idx;
+ALTER TABLE cards DROP COLUMN tags;
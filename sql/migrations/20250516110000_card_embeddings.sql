@@ -0,0 +1,12 @@
+-- +goose Up
+-- embeddings are stored as plain arrays so the table works without pgvector,
+-- when the extension is installed they are cast to vector at query time
+CREATE TABLE card_embeddings(
+    card_id UUID PRIMARY KEY REFERENCES cards(id) ON DELETE CASCADE,
+    model TEXT NOT NULL,
+    embedding REAL[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE card_embeddings;
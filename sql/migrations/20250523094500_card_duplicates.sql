@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE cards ADD COLUMN duplicate_of UUID REFERENCES cards(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE cards DROP COLUMN duplicate_of;
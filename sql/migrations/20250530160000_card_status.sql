@@ -0,0 +1,11 @@
+-- +goose Up
+-- generated cards start as drafts and only enter the deck once the user accepts them
+ALTER TABLE cards ADD COLUMN status TEXT NOT NULL DEFAULT 'active'
+    CHECK (status IN ('draft', 'active', 'rejected'));
+ALTER TABLE cards ADD COLUMN file_id UUID REFERENCES files(id) ON DELETE SET NULL;
+CREATE INDEX cards_file_status_idx ON cards (file_id, status);
+
+-- +goose Down
+DROP INDEX cards_file_status_idx;
+ALTER TABLE cards DROP COLUMN file_id;
+ALTER TABLE cards DROP COLUMN status;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE cards ADD COLUMN source_page_start INT;
+ALTER TABLE cards ADD COLUMN source_page_end INT;
+ALTER TABLE cards ADD COLUMN source_excerpt TEXT;
+
+-- +goose Down
+ALTER TABLE cards DROP COLUMN source_excerpt;
+ALTER TABLE cards DROP COLUMN source_page_end;
+ALTER TABLE cards DROP COLUMN source_page_start;
@@ -0,0 +1,6 @@
+-- +goose Up
+ALTER TABLE cards ADD COLUMN content_format TEXT NOT NULL DEFAULT 'plain'
+    CHECK (content_format IN ('plain', 'markdown'));
+
+-- +goose Down
+ALTER TABLE cards DROP COLUMN content_format;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- images and audio stored in S3 under media/<id>, referenced from card text as media:<id>.
+-- file_id and page are set for figures extracted from an uploaded PDF
+CREATE TABLE card_media(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    card_id UUID REFERENCES cards(id) ON DELETE SET NULL,
+    file_id UUID REFERENCES files(id) ON DELETE SET NULL,
+    page INT,
+    content_type TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('image', 'audio')),
+    uploaded BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX card_media_collection_idx ON card_media(collection_id);
+
+-- +goose Down
+DROP TABLE card_media;
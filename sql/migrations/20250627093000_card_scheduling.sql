@@ -0,0 +1,28 @@
+-- +goose Up
+-- spaced repetition state next to cards.due_date, intervals in days and
+-- ease in permille like Anki so imported decks keep their schedule
+CREATE TABLE card_schedules(
+    card_id UUID PRIMARY KEY REFERENCES cards(id) ON DELETE CASCADE,
+    state TEXT NOT NULL DEFAULT 'new' CHECK (state IN ('new', 'learning', 'review', 'relearning')),
+    interval_days INT NOT NULL DEFAULT 0,
+    ease_factor INT NOT NULL DEFAULT 2500,
+    reps INT NOT NULL DEFAULT 0,
+    lapses INT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE card_reviews(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    card_id UUID NOT NULL REFERENCES cards(id) ON DELETE CASCADE,
+    reviewed_at TIMESTAMP NOT NULL,
+    -- 1 again, 2 hard, 3 good, 4 easy
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 4),
+    interval_days INT NOT NULL,
+    ease_factor INT NOT NULL,
+    duration_ms INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX card_reviews_card_idx ON card_reviews(card_id, reviewed_at);
+
+-- +goose Down
+DROP TABLE card_reviews;
+DROP TABLE card_schedules;
@@ -0,0 +1,22 @@
+-- +goose Up
+-- cards synced from a markdown vault remember their note and position in it,
+-- so importing the vault again updates them instead of adding copies
+ALTER TABLE cards ADD COLUMN note_key TEXT;
+ALTER TABLE cards ADD COLUMN note_ordinal INT;
+CREATE INDEX cards_note_idx ON cards(collection_id, note_key) WHERE note_key IS NOT NULL;
+
+-- notes of the last vault import, unchanged notes are skipped next time
+CREATE TABLE vault_notes(
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    note_key TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    file_id UUID REFERENCES files(id) ON DELETE SET NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (collection_id, note_key)
+);
+
+-- +goose Down
+DROP TABLE vault_notes;
+DROP INDEX cards_note_idx;
+ALTER TABLE cards DROP COLUMN note_ordinal;
+ALTER TABLE cards DROP COLUMN note_key;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- printable exports are rendered by the worker, the client polls for the file
+CREATE TABLE pdf_exports(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    layout TEXT NOT NULL CHECK (layout IN ('cards', 'sheet')),
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE pdf_exports;
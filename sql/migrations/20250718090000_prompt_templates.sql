@@ -0,0 +1,13 @@
+-- +goose Up
+-- custom card prompts of a collection, every save is a new version and the latest one is used
+CREATE TABLE prompt_templates(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    collection_id UUID NOT NULL REFERENCES collections(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (collection_id, version)
+);
+
+-- +goose Down
+DROP TABLE prompt_templates;
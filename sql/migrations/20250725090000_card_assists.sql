@@ -0,0 +1,14 @@
+-- +goose Up
+-- hints and explanations written for a card, kept until the card changes
+CREATE TABLE card_assists(
+    card_id UUID NOT NULL REFERENCES cards(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    -- hash of the card content the text was written for
+    revision TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (card_id, kind)
+);
+
+-- +goose Down
+DROP TABLE card_assists;
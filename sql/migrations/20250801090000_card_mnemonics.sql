@@ -0,0 +1,17 @@
+-- +goose Up
+-- mnemonics written for hard cards, shown with the card once the user approves one
+CREATE TABLE card_mnemonics(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    card_id UUID NOT NULL REFERENCES cards(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    body TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX card_mnemonics_card_idx ON card_mnemonics(card_id);
+-- a card shows one mnemonic at most
+CREATE UNIQUE INDEX card_mnemonics_approved_idx ON card_mnemonics(card_id) WHERE status = 'approved';
+
+-- +goose Down
+DROP TABLE card_mnemonics;
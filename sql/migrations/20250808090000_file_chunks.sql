@@ -0,0 +1,18 @@
+-- +goose Up
+-- text of processed files in short passages, the material chat answers come from.
+-- embeddings are plain arrays like card_embeddings, cast to vector when pgvector is installed
+CREATE TABLE file_chunks(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    -- 0 for text without pages
+    page INT NOT NULL DEFAULT 0,
+    body TEXT NOT NULL,
+    model TEXT NOT NULL,
+    embedding REAL[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (file_id, position)
+);
+
+-- +goose Down
+DROP TABLE file_chunks;
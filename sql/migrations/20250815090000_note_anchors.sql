@@ -0,0 +1,10 @@
+-- +goose Up
+-- vault cards are matched on re-import by their Obsidian ^block id, or a hash of
+-- the front when they have none, instead of their position in the note.
+-- note_ordinal stays for the order
+ALTER TABLE cards ADD COLUMN note_anchor TEXT;
+UPDATE cards SET note_anchor = 'h:' || left(encode(sha256(convert_to(front, 'UTF8')), 'hex'), 16)
+WHERE note_key IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE cards DROP COLUMN note_anchor;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- pasted text cards were generated from, there is no uploaded object to read it back from
+CREATE TABLE file_texts(
+    file_id UUID PRIMARY KEY REFERENCES files(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE file_texts;
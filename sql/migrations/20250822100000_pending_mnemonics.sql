@@ -0,0 +1,11 @@
+-- +goose Up
+-- a card has one pending suggestion at most, jobs racing on a leech keep the first
+DELETE FROM card_mnemonics m
+USING card_mnemonics earlier
+WHERE m.card_id = earlier.card_id AND m.status = 'pending' AND earlier.status = 'pending'
+  AND (earlier.created_at, earlier.id) < (m.created_at, m.id);
+
+CREATE UNIQUE INDEX card_mnemonics_pending_idx ON card_mnemonics(card_id) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX card_mnemonics_pending_idx;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- drafts proposing a merged back for the card in their duplicate_of, applied when accepted
+CREATE TABLE card_merge_drafts(
+    card_id UUID PRIMARY KEY REFERENCES cards(id) ON DELETE CASCADE
+);
+
+-- +goose Down
+DROP TABLE card_merge_drafts;
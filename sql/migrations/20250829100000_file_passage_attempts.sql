@@ -0,0 +1,9 @@
+-- +goose Up
+-- files the passage backfill has read, ones it couldn't read aren't read again
+CREATE TABLE file_passage_attempts(
+    file_id UUID PRIMARY KEY REFERENCES files(id) ON DELETE CASCADE,
+    attempted_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE file_passage_attempts;
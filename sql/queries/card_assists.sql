@@ -0,0 +1,9 @@
+-- name: GetCardAssist :one
+SELECT card_id, kind, revision, body, created_at FROM card_assists
+WHERE card_id=$1 AND kind=$2 AND revision=$3;
+
+-- name: SaveCardAssist :one
+INSERT INTO card_assists(card_id, kind, revision, body)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (card_id, kind) DO UPDATE SET revision=EXCLUDED.revision, body=EXCLUDED.body, created_at=NOW()
+RETURNING card_id, kind, revision, body, created_at;
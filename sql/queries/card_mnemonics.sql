@@ -0,0 +1,47 @@
+-- name: ListMnemonicCandidates :many
+SELECT cards.id, cards.front, cards.back, cards.content_format, COALESCE(card_schedules.lapses, 0)::int AS lapses
+FROM cards
+LEFT JOIN card_schedules ON card_schedules.card_id = cards.id
+WHERE cards.collection_id = @collection_id AND cards.status = 'active' AND cards.deleted_at IS NULL
+  AND (card_schedules.lapses >= @min_lapses::int OR 'leech' = ANY(cards.tags))
+  AND NOT EXISTS (
+    SELECT 1 FROM card_mnemonics
+    WHERE card_mnemonics.card_id = cards.id AND card_mnemonics.status IN ('pending', 'approved')
+  )
+ORDER BY lapses DESC, cards.created_at
+LIMIT @max_cards;
+
+-- name: CreateCardMnemonic :execrows
+INSERT INTO card_mnemonics(card_id, kind, body) VALUES ($1, $2, $3)
+ON CONFLICT (card_id) WHERE status = 'pending' DO NOTHING;
+
+-- name: ListCardMnemonics :many
+SELECT card_mnemonics.id, card_mnemonics.card_id, cards.front, cards.back, cards.content_format,
+    card_mnemonics.kind, card_mnemonics.body, card_mnemonics.status, card_mnemonics.created_at
+FROM card_mnemonics
+JOIN cards ON cards.id = card_mnemonics.card_id
+WHERE cards.collection_id = $1 AND card_mnemonics.status = $2 AND cards.deleted_at IS NULL
+ORDER BY card_mnemonics.created_at, cards.front;
+
+-- name: ListApprovedMnemonics :many
+SELECT card_mnemonics.card_id, card_mnemonics.body
+FROM card_mnemonics
+JOIN cards ON cards.id = card_mnemonics.card_id
+WHERE cards.collection_id = $1 AND card_mnemonics.status = 'approved';
+
+-- name: ApproveCardMnemonic :execrows
+UPDATE card_mnemonics SET status = 'approved'
+WHERE id = $1 AND card_id IN (SELECT id FROM cards WHERE collection_id = $2);
+
+-- name: RejectCardMnemonic :execrows
+UPDATE card_mnemonics SET status = 'rejected'
+WHERE id = $1 AND card_id IN (SELECT id FROM cards WHERE collection_id = $2);
+
+-- name: RejectOtherMnemonics :exec
+UPDATE card_mnemonics SET status = 'rejected'
+WHERE card_mnemonics.status = 'approved' AND card_mnemonics.id <> @id
+  AND card_mnemonics.card_id = (
+    SELECT m.card_id FROM card_mnemonics m
+    JOIN cards ON cards.id = m.card_id
+    WHERE m.id = @id AND cards.collection_id = @collection_id
+  );
@@ -1,28 +1,152 @@
 -- name: GetCardsFomCollection :many
-SELECT * FROM cards WHERE collection_id=$1;
+SELECT * FROM cards WHERE collection_id=$1 AND status='active' AND deleted_at IS NULL;
 
 -- name: GetCard :one
 SELECT cards.*
 FROM cards
 JOIN collections ON cards.collection_id = collections.id
-WHERE cards.id = $1 AND collections.user_id = $2;
+WHERE cards.id = $1 AND collections.user_id = $2
+  AND cards.deleted_at IS NULL AND collections.deleted_at IS NULL;
 
 -- name: CreateCard :one
 INSERT INTO cards(
-    front, back, created_at, collection_id
+    front, back, created_at, collection_id, content_format
 ) VALUES 
-    ($1, $2, NOW(), $3)
+    ($1, $2, NOW(), $3, $4)
 RETURNING id;
 
 
 -- name: DeleteCard :exec
-DELETE FROM cards WHERE id=$1 and collection_id=$2;
+UPDATE cards SET deleted_at=NOW() WHERE id=$1 and collection_id=$2 AND deleted_at IS NULL;
 
 -- name: DeleteAllCards :exec
-DELETE FROM cards WHERE collection_id=$1;
+UPDATE cards SET deleted_at=NOW() WHERE collection_id=$1 AND deleted_at IS NULL;
 
 -- name: GetTotalCardCount :one
-SELECT COUNT(*) FROM cards WHERE collection_id= $1;
+SELECT COUNT(*) FROM cards WHERE collection_id= $1 AND status='active' AND deleted_at IS NULL;
 
--- name: UpdateCard :exec
-UPDATE cards SET front=$1, back=$2 WHERE id=$3;
+-- name: UpdateCard :execrows
+UPDATE cards SET front=@front, back=@back, tags=COALESCE(sqlc.narg(tags)::text[], tags),
+    content_format=COALESCE(NULLIF(@content_format::text, ''), content_format)
+WHERE id=@id AND collection_id=@collection_id AND deleted_at IS NULL;
+
+-- name: SetCardTags :exec
+UPDATE cards SET tags=$1 WHERE id=$2;
+
+-- name: ListDeletedCards :many
+SELECT cards.*
+FROM cards
+JOIN collections ON cards.collection_id = collections.id
+WHERE collections.user_id = $1
+  AND cards.deleted_at IS NOT NULL AND collections.deleted_at IS NULL
+ORDER BY cards.deleted_at DESC;
+
+-- name: RestoreCard :execrows
+UPDATE cards SET deleted_at=NULL
+FROM collections
+WHERE cards.collection_id = collections.id
+  AND cards.id = $1 AND collections.user_id = $2
+  AND cards.deleted_at IS NOT NULL AND collections.deleted_at IS NULL;
+
+-- name: RestoreAllCards :exec
+UPDATE cards SET deleted_at=NULL
+FROM collections
+WHERE cards.collection_id = collections.id
+  AND collections.id = $1 AND cards.deleted_at = collections.deleted_at;
+
+-- name: RestoreFileCards :exec
+UPDATE cards SET deleted_at=NULL
+FROM files
+WHERE cards.file_id = files.id
+  AND files.id = $1 AND cards.deleted_at = files.deleted_at;
+
+-- name: PurgeCards :exec
+DELETE FROM cards
+WHERE deleted_at < $1
+   OR collection_id IN (SELECT id FROM collections WHERE collections.deleted_at < $1);
+
+-- name: SetCardDuplicateOf :exec
+UPDATE cards SET duplicate_of=$1 WHERE id=$2;
+
+-- name: ImportCard :one
+INSERT INTO cards(
+    front, back, created_at, due_date, collection_id, file_id, tags, status, content_format
+) VALUES
+    ($1, $2, NOW(), COALESCE($3::timestamp, NOW()), $4, $5, $6, 'active', 'markdown')
+RETURNING id;
+
+-- name: ListCollectionCardTexts :many
+SELECT id, front, back FROM cards WHERE collection_id=$1 AND status='active' AND deleted_at IS NULL;
+
+-- name: ListDraftCards :many
+SELECT cards.*
+FROM cards
+JOIN collections ON cards.collection_id = collections.id
+WHERE cards.file_id = $1 AND collections.user_id = $2 AND cards.status = 'draft'
+  AND cards.deleted_at IS NULL AND collections.deleted_at IS NULL
+ORDER BY cards.created_at;
+
+-- name: ListRecentCardFronts :many
+SELECT front FROM cards
+WHERE collection_id=$1 AND status='active' AND deleted_at IS NULL
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: SetDraftsStatus :execrows
+UPDATE cards SET status=@status
+FROM collections
+WHERE cards.collection_id = collections.id
+  AND cards.file_id = @file_id AND collections.user_id = @user_id
+  AND cards.id = ANY(@card_ids::uuid[])
+  AND cards.status = 'draft' AND cards.deleted_at IS NULL;
+
+-- name: SetAllDraftsStatus :execrows
+UPDATE cards SET status=@status
+FROM collections
+WHERE cards.collection_id = collections.id
+  AND cards.file_id = @file_id AND collections.user_id = @user_id
+  AND cards.status = 'draft' AND cards.deleted_at IS NULL;
+
+-- name: DeleteFileCards :exec
+UPDATE cards SET deleted_at=NOW() WHERE file_id=$1 AND collection_id=$2 AND deleted_at IS NULL;
+
+-- name: InsertCards :exec
+INSERT INTO cards(
+    id, front, back, created_at, due_date, collection_id, file_id, tags, status,
+    duplicate_of, source_page_start, source_page_end, source_excerpt, content_format,
+    note_key, note_ordinal, note_anchor
+)
+SELECT u.id, u.front, u.back, NOW(), COALESCE(u.due_date, NOW()), @collection_id, u.file_id,
+    ARRAY(SELECT jsonb_array_elements_text(u.tags)), @status,
+    u.duplicate_of, u.page_start, u.page_end, u.excerpt, @content_format,
+    u.note_key, u.note_ordinal, u.note_anchor
+FROM unnest(
+    @ids::uuid[], @fronts::text[], @backs::text[], @due_dates::timestamp[], @file_ids::uuid[],
+    @tags::jsonb[], @duplicate_ofs::uuid[], @page_starts::int[], @page_ends::int[], @excerpts::text[],
+    @note_keys::text[], @note_ordinals::int[], @note_anchors::text[]
+) AS u(id, front, back, due_date, file_id, tags, duplicate_of, page_start, page_end, excerpt, note_key, note_ordinal, note_anchor);
+
+-- name: SetCardSource :exec
+UPDATE cards SET file_id=$2, source_page_start=$3, source_page_end=$4, source_excerpt=$5 WHERE id=$1;
+
+-- name: CreateMergeDrafts :exec
+INSERT INTO card_merge_drafts(card_id) SELECT unnest(@card_ids::uuid[]);
+
+-- name: ListMergeDrafts :many
+SELECT card_merge_drafts.card_id
+FROM card_merge_drafts
+JOIN cards ON card_merge_drafts.card_id = cards.id
+WHERE cards.file_id = $1 AND cards.status = 'draft' AND cards.deleted_at IS NULL;
+
+-- name: ApplyMergeDrafts :many
+WITH applied AS (
+    UPDATE cards SET back = drafts.back
+    FROM cards drafts
+    JOIN card_merge_drafts ON card_merge_drafts.card_id = drafts.id
+    WHERE cards.id = drafts.duplicate_of AND drafts.file_id = $1 AND drafts.status = 'active'
+      AND drafts.deleted_at IS NULL AND cards.deleted_at IS NULL
+    RETURNING cards.id AS card_id, drafts.id AS draft_id
+)
+DELETE FROM cards USING applied
+WHERE cards.id = applied.draft_id
+RETURNING applied.card_id;
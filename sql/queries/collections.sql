@@ -8,14 +8,29 @@ RETURNING id;
 
 
 -- name: GetCollectionById :one
-SELECT * FROM collections WHERE id=$1 and user_id=$2;
+SELECT * FROM collections WHERE id=$1 and user_id=$2 AND deleted_at IS NULL;
 
 -- name: CheckUserCollectionOwnership :one
-SELECT 1 FROM collections WHERE id = $1 AND user_id = $2;
+SELECT 1 FROM collections WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL;
 
 -- name: ListCollections :many
-SELECT id, name FROM collections WHERE user_id=$1;
+SELECT id, name FROM collections WHERE user_id=$1 AND deleted_at IS NULL;
 
 -- name: DeleteCollection :exec
-DELETE FROM collections WHERE id=$1 and user_id=$2;
+UPDATE collections SET deleted_at=NOW() WHERE id=$1 and user_id=$2 AND deleted_at IS NULL;
 
+-- name: ListDeletedCollections :many
+SELECT * FROM collections WHERE user_id=$1 AND deleted_at IS NOT NULL
+ORDER BY deleted_at DESC;
+
+-- name: RestoreCollection :execrows
+UPDATE collections SET deleted_at=NULL WHERE id=$1 and user_id=$2 AND deleted_at IS NOT NULL;
+
+-- name: PurgeCollections :exec
+DELETE FROM collections
+WHERE deleted_at < @deleted_at
+  AND NOT EXISTS (SELECT 1 FROM files WHERE files.collection_id = collections.id)
+  AND NOT (id = ANY(@kept::uuid[]));
+
+-- name: LockLiveCollection :one
+SELECT id FROM collections WHERE id=$1 AND deleted_at IS NULL FOR SHARE;
@@ -0,0 +1,41 @@
+-- name: UpsertCardEmbedding :exec
+INSERT INTO card_embeddings(
+    card_id, model, embedding
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (card_id) DO UPDATE SET model=EXCLUDED.model, embedding=EXCLUDED.embedding, created_at=NOW();
+
+-- name: ListCardTexts :many
+SELECT id, front, back FROM cards WHERE id = ANY(@ids::uuid[]) AND deleted_at IS NULL;
+
+-- name: DeleteCardEmbedding :exec
+DELETE FROM card_embeddings WHERE card_id=$1;
+
+-- name: GetCardEmbedding :one
+SELECT card_embeddings.embedding
+FROM card_embeddings
+JOIN cards ON card_embeddings.card_id = cards.id
+JOIN collections ON cards.collection_id = collections.id
+WHERE cards.id = $1 AND collections.user_id = $2 AND card_embeddings.model = $3
+  AND cards.deleted_at IS NULL AND collections.deleted_at IS NULL;
+
+-- name: ListUserCardEmbeddings :many
+SELECT cards.id, cards.front, cards.back, cards.collection_id, cards.tags, cards.content_format, card_embeddings.embedding
+FROM card_embeddings
+JOIN cards ON card_embeddings.card_id = cards.id
+JOIN collections ON cards.collection_id = collections.id
+WHERE collections.user_id = $1 AND card_embeddings.model = $2 AND cards.status = 'active'
+  AND cards.deleted_at IS NULL AND collections.deleted_at IS NULL
+ORDER BY cards.created_at DESC
+LIMIT $3;
+
+-- name: HasVectorExtension :one
+SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'vector');
+
+-- name: ListCollectionCardEmbeddings :many
+SELECT card_embeddings.card_id, card_embeddings.embedding
+FROM card_embeddings
+JOIN cards ON card_embeddings.card_id = cards.id
+WHERE cards.collection_id = $1 AND card_embeddings.model = $2 AND cards.status = 'active'
+  AND cards.deleted_at IS NULL;
@@ -0,0 +1,16 @@
+-- name: ListCardsForExport :many
+SELECT cards.id, cards.front, cards.back, cards.tags, cards.content_format, cards.due_date, cards.created_at,
+    card_schedules.state, card_schedules.interval_days, card_schedules.ease_factor,
+    card_schedules.reps, card_schedules.lapses
+FROM cards
+LEFT JOIN card_schedules ON card_schedules.card_id = cards.id
+WHERE cards.collection_id = $1 AND cards.status = 'active' AND cards.deleted_at IS NULL
+ORDER BY cards.created_at, cards.id;
+
+-- name: ListCollectionReviews :many
+SELECT card_reviews.card_id, card_reviews.reviewed_at, card_reviews.rating,
+    card_reviews.interval_days, card_reviews.ease_factor, card_reviews.duration_ms
+FROM card_reviews
+JOIN cards ON card_reviews.card_id = cards.id
+WHERE cards.collection_id = $1 AND cards.status = 'active' AND cards.deleted_at IS NULL
+ORDER BY card_reviews.reviewed_at;
@@ -0,0 +1,30 @@
+-- name: CreateFileChunk :exec
+INSERT INTO file_chunks(file_id, position, page, body, model, embedding)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: CreateFilePassageAttempt :exec
+INSERT INTO file_passage_attempts(file_id) VALUES ($1)
+ON CONFLICT (file_id) DO NOTHING;
+
+-- name: DeleteFileChunks :exec
+DELETE FROM file_chunks WHERE file_id=$1;
+
+-- name: GetFileChunk :one
+SELECT file_chunks.id, file_chunks.file_id, files.file_name, file_chunks.page, file_chunks.body
+FROM file_chunks
+JOIN files ON files.id = file_chunks.file_id
+WHERE file_chunks.id = $1 AND files.collection_id = $2 AND files.deleted_at IS NULL;
+
+-- name: ListFileChunkEmbeddings :many
+SELECT file_chunks.id, file_chunks.file_id, files.file_name, file_chunks.page, file_chunks.body, file_chunks.embedding
+FROM file_chunks
+JOIN files ON files.id = file_chunks.file_id
+WHERE files.collection_id = @collection_id AND file_chunks.model = @model AND files.deleted_at IS NULL
+  AND (sqlc.narg(file_id)::uuid IS NULL OR file_chunks.file_id = sqlc.narg(file_id)::uuid)
+ORDER BY files.uploaded_at DESC, file_chunks.position
+LIMIT @max_chunks;
+
+-- name: ListFileChunkPages :many
+SELECT body FROM file_chunks
+WHERE file_id = @file_id AND page BETWEEN @first_page::int AND @last_page::int
+ORDER BY position;
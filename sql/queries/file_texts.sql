@@ -0,0 +1,5 @@
+-- name: CreateFileText :exec
+INSERT INTO file_texts(file_id, body) VALUES ($1, $2);
+
+-- name: GetFileText :one
+SELECT body FROM file_texts WHERE file_id=$1;
@@ -1,8 +1,8 @@
 -- name: GetFilesForCollection :many
-SELECT * FROM files WHERE collection_id=$1 and user_id = $2;
+SELECT * FROM files WHERE collection_id=$1 and user_id = $2 AND deleted_at IS NULL;
 
 -- name: DeleteFile :exec
-DELETE FROM files WHERE id=$1;
+UPDATE files SET deleted_at=NOW() WHERE id=$1 AND deleted_at IS NULL;
 
 -- name: CraeteFileEntry :one
 INSERT INTO files(
@@ -23,4 +23,54 @@ SELECT processed FROM files WHERE id = $1;
 UPDATE files SET processed = $1 WHERE id = $2;
 
 -- name: DeleteAllFiles :exec
-DELETE FROM files WHERE collection_id=$1 and user_id=$2;
\ No newline at end of file
+UPDATE files SET deleted_at=NOW() WHERE collection_id=$1 and user_id=$2 AND deleted_at IS NULL;
+
+-- name: ListDeletedFiles :many
+SELECT files.*
+FROM files
+JOIN collections ON files.collection_id = collections.id
+WHERE files.user_id = $1 AND files.file_name IS NOT NULL
+  AND files.deleted_at IS NOT NULL AND collections.deleted_at IS NULL
+ORDER BY files.deleted_at DESC;
+
+-- name: RestoreFile :execrows
+UPDATE files SET deleted_at=NULL
+FROM collections
+WHERE files.collection_id = collections.id
+  AND files.id = $1 AND files.user_id = $2
+  AND files.deleted_at IS NOT NULL AND collections.deleted_at IS NULL;
+
+-- name: RestoreAllFiles :exec
+UPDATE files SET deleted_at=NULL
+FROM collections
+WHERE files.collection_id = collections.id
+  AND collections.id = $1 AND files.deleted_at = collections.deleted_at;
+
+-- name: ListPurgeableFiles :many
+SELECT id FROM files WHERE deleted_at < $1;
+
+-- name: PurgeFile :exec
+DELETE FROM files WHERE id=$1;
+
+-- name: DeleteCollectionFile :execrows
+UPDATE files SET deleted_at=NOW() WHERE id=$1 AND collection_id=$2 AND deleted_at IS NULL;
+
+-- name: GetFileName :one
+SELECT file_name FROM files WHERE id=$1;
+
+-- name: GetCollectionFileName :one
+SELECT file_name FROM files WHERE id=$1 AND collection_id=$2;
+
+-- name: ListCollectionFileNames :many
+SELECT id, file_name FROM files WHERE collection_id=$1;
+
+-- name: GetFileFormat :one
+SELECT format FROM files WHERE id=$1;
+
+-- name: ListFilesWithoutPassages :many
+SELECT id, format FROM files
+WHERE processed AND deleted_at IS NULL AND format NOT IN ('apkg', 'zip', 'topic') AND id > @after::uuid
+  AND NOT EXISTS (SELECT 1 FROM file_chunks WHERE file_chunks.file_id = files.id)
+  AND NOT EXISTS (SELECT 1 FROM file_passage_attempts WHERE file_passage_attempts.file_id = files.id)
+ORDER BY id
+LIMIT @max_files;
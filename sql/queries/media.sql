@@ -0,0 +1,51 @@
+-- name: CreateMedia :one
+INSERT INTO card_media(
+    collection_id, card_id, content_type, kind
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING id;
+
+-- name: CreateFileFigure :one
+INSERT INTO card_media(
+    collection_id, file_id, page, content_type, kind, uploaded
+) VALUES (
+    $1, $2, $3, $4, 'image', TRUE
+)
+RETURNING id;
+
+-- name: MarkMediaUploaded :execrows
+UPDATE card_media SET uploaded=TRUE WHERE id=$1 AND collection_id=$2;
+
+-- name: AttachMedia :exec
+UPDATE card_media SET card_id=$1 WHERE id=$2;
+
+-- name: ListCollectionMedia :many
+SELECT * FROM card_media WHERE collection_id=$1 AND uploaded ORDER BY created_at;
+
+-- name: ListCardsMedia :many
+SELECT * FROM card_media
+WHERE collection_id=@collection_id AND uploaded
+  AND (card_id = ANY(@card_ids::uuid[]) OR id = ANY(@media_ids::uuid[]))
+ORDER BY created_at;
+
+-- name: GetMedia :one
+SELECT * FROM card_media WHERE id=$1 AND collection_id=$2;
+
+-- name: DeleteMedia :execrows
+DELETE FROM card_media WHERE id=$1 AND collection_id=$2;
+
+-- name: ListPurgeableMedia :many
+SELECT card_media.id, card_media.collection_id
+FROM card_media
+JOIN collections ON card_media.collection_id = collections.id
+WHERE collections.deleted_at < $1
+  AND NOT EXISTS (SELECT 1 FROM files WHERE files.collection_id = collections.id);
+
+-- name: CreateUploadedMedia :one
+INSERT INTO card_media(
+    collection_id, card_id, content_type, kind, uploaded
+) VALUES (
+    $1, $2, $3, $4, TRUE
+)
+RETURNING id;
@@ -0,0 +1,16 @@
+-- name: CreatePdfExport :one
+INSERT INTO pdf_exports(collection_id, user_id, layout) VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: GetPdfExport :one
+SELECT id, collection_id, user_id, layout, status, created_at, completed_at FROM pdf_exports
+WHERE id=$1 AND collection_id=$2 AND user_id=$3;
+
+-- name: SetPdfExportStatus :exec
+UPDATE pdf_exports SET status=$1, completed_at=NOW() WHERE id=$2;
+
+-- name: ListExpiredPdfExports :many
+SELECT id FROM pdf_exports WHERE created_at < $1;
+
+-- name: DeletePdfExport :exec
+DELETE FROM pdf_exports WHERE id=$1;
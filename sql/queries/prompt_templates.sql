@@ -0,0 +1,19 @@
+-- name: CreatePromptTemplate :one
+INSERT INTO prompt_templates(collection_id, version, body)
+SELECT $1, COALESCE(MAX(version), 0) + 1, $2 FROM prompt_templates WHERE collection_id=$1
+RETURNING id, collection_id, version, body, created_at;
+
+-- name: GetLatestPromptTemplate :one
+SELECT id, collection_id, version, body, created_at FROM prompt_templates
+WHERE collection_id=$1
+ORDER BY version DESC
+LIMIT 1;
+
+-- name: GetPromptTemplateVersion :one
+SELECT id, collection_id, version, body, created_at FROM prompt_templates
+WHERE collection_id=$1 AND version=$2;
+
+-- name: ListPromptTemplates :many
+SELECT id, collection_id, version, body, created_at FROM prompt_templates
+WHERE collection_id=$1
+ORDER BY version DESC;
@@ -0,0 +1,19 @@
+-- name: UpsertCardSchedule :exec
+INSERT INTO card_schedules(
+    card_id, state, interval_days, ease_factor, reps, lapses
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (card_id) DO UPDATE SET
+    state = EXCLUDED.state,
+    interval_days = EXCLUDED.interval_days,
+    ease_factor = EXCLUDED.ease_factor,
+    reps = EXCLUDED.reps,
+    lapses = EXCLUDED.lapses;
+
+-- name: CreateCardReview :exec
+INSERT INTO card_reviews(
+    card_id, reviewed_at, rating, interval_days, ease_factor, duration_ms
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);
@@ -0,0 +1,16 @@
+-- name: SearchCards :many
+SELECT cards.id, cards.front, cards.back, cards.collection_id, cards.tags, cards.content_format,
+    collections.name AS collection_name,
+    ts_rank(setweight(to_tsvector('english', cards.front), 'A') || setweight(to_tsvector('english', cards.back), 'B'), query) AS rank,
+    ts_headline('english', replace(replace(replace(cards.front, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), query, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS front_snippet,
+    ts_headline('english', replace(replace(replace(cards.back, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), query, 'StartSel=<mark>, StopSel=</mark>, MaxFragments=2') AS back_snippet
+FROM cards
+JOIN collections ON cards.collection_id = collections.id
+CROSS JOIN websearch_to_tsquery('english', @query::text) AS query
+WHERE collections.user_id = @user_id AND cards.status = 'active'
+  AND cards.deleted_at IS NULL AND collections.deleted_at IS NULL
+  AND (setweight(to_tsvector('english', cards.front), 'A') || setweight(to_tsvector('english', cards.back), 'B')) @@ query
+  AND (sqlc.narg(collection_id)::uuid IS NULL OR cards.collection_id = sqlc.narg(collection_id)::uuid)
+  AND cards.tags @> @tags::text[]
+ORDER BY rank DESC
+LIMIT @max_results;
@@ -0,0 +1,30 @@
+-- name: ListVaultNotes :many
+SELECT note_key, content_hash FROM vault_notes WHERE collection_id=$1;
+
+-- name: UpsertVaultNote :exec
+INSERT INTO vault_notes(
+    collection_id, note_key, content_hash, file_id, updated_at
+) VALUES (
+    $1, $2, $3, $4, NOW()
+)
+ON CONFLICT (collection_id, note_key) DO UPDATE SET
+    content_hash = EXCLUDED.content_hash,
+    file_id = EXCLUDED.file_id,
+    updated_at = NOW();
+
+-- name: DeleteVaultNote :exec
+DELETE FROM vault_notes WHERE collection_id=$1 AND note_key=$2;
+
+-- name: ListNoteCards :many
+SELECT id, note_ordinal, note_anchor, front, back, tags, status FROM cards
+WHERE collection_id=$1 AND note_key=$2 AND deleted_at IS NULL
+ORDER BY note_ordinal;
+
+-- name: UpdateNoteCard :exec
+UPDATE cards SET front=$1, back=$2, tags=$3, note_ordinal=$4, note_anchor=$5 WHERE id=$6;
+
+-- name: DeleteNoteCards :execrows
+UPDATE cards SET deleted_at=NOW()
+WHERE collection_id=@collection_id AND note_key=@note_key AND deleted_at IS NULL
+  AND NOT (id = ANY(@keep_ids::uuid[]))
+  AND (status = 'draft' OR NOT @drafts_only::bool);